@@ -5,9 +5,16 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"time"
 
 	"github.com/victormamede/benebott/internal/capabilities"
 	"github.com/victormamede/benebott/internal/chat"
+	"github.com/victormamede/benebott/internal/gamenight"
+	"github.com/victormamede/benebott/internal/history"
+	"github.com/victormamede/benebott/internal/i18n"
+	"github.com/victormamede/benebott/internal/memory"
+	"github.com/victormamede/benebott/internal/storage"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
@@ -23,11 +30,35 @@ func main() {
 	viper.AddConfigPath(".")
 	viper.AddConfigPath("/etc/benebott")
 
+	viper.SetDefault("bot.data_dir", "data")
+	viper.SetDefault("bot.timezone", "Local")
+	viper.SetDefault("bot.language", "pt")
+	viper.SetDefault("bot.persona", "benebott")
+	viper.SetDefault("history.embedding_model", "text-embedding-004")
+	viper.SetDefault("history.dimensions", 256)
+	viper.SetDefault("history.max_messages", 20000)
+	viper.SetDefault("bot.retry.attempts", 3)
+	viper.SetDefault("bot.retry.backoff", "1s")
+	viper.SetDefault("bot.translator_context", 5)
+	viper.SetDefault("behaviors.cooldown", "10m")
+	viper.SetDefault("participation.model", "gemini-2.0-flash-lite")
+	viper.SetDefault("participation.threshold", 0.8)
+	viper.SetDefault("participation.daily_cap", 5)
+	viper.SetDefault("participation.context", 10)
+	viper.SetDefault("behaviors.reactions", []string{"😁", "🤡", "👍", "🔥", "🤔"})
+	viper.SetDefault("gamenight.close_before", "1h")
+	viper.SetDefault("gamenight.remind_before", "15m")
+
 	err := viper.ReadInConfig()
 	if err != nil {
 		panic(fmt.Errorf("fatal error config file: %w", err))
 	}
 
+	location, err := time.LoadLocation(viper.GetString("bot.timezone"))
+	if err != nil {
+		panic(fmt.Errorf("fatal error time zone: %w", err))
+	}
+
 	// Init gemini
 	aiClient, err := genai.NewClient(ctx, &genai.ClientConfig{
 		APIKey:  viper.GetString("keys.gemini"),
@@ -37,15 +68,128 @@ func main() {
 		panic(err)
 	}
 
-	config := &genai.GenerateContentConfig{
-		SystemInstruction: genai.NewContentFromText(viper.GetString("bot.prompt"), genai.RoleUser),
-		Tools:             capabilities.Tools,
+	catalog, err := i18n.Load(viper.GetString("bot.language"))
+	if err != nil {
+		panic(fmt.Errorf("fatal error language: %w", err))
+	}
+
+	dataDir := viper.GetString("bot.data_dir")
+	chatLanguages, err := storage.Open(dataDir, "languages", map[int64]string{})
+	if err != nil {
+		panic(err)
+	}
+	languages := i18n.NewLanguages(catalog, chatLanguages)
+
+	gameNights, err := storage.Open(dataDir, "gamenights", map[int64]*gamenight.GameNight{})
+	if err != nil {
+		panic(err)
+	}
+
+	translatorRules, err := storage.Open(dataDir, "translator", map[int64]map[int64]*chat.TranslatorRule{})
+	if err != nil {
+		panic(err)
+	}
+
+	glossary, err := storage.Open(dataDir, "glossary", map[int64]map[string]string{})
+	if err != nil {
+		panic(err)
+	}
+
+	behaviorChances, err := storage.Open(dataDir, "behaviors", map[int64]map[string]float64{})
+	if err != nil {
+		panic(err)
+	}
+
+	participation, err := storage.Open(dataDir, "participation", map[int64]bool{})
+	if err != nil {
+		panic(err)
+	}
+
+	chatSettings, err := storage.Open(dataDir, "settings", map[int64]*chat.ChatSettings{})
+	if err != nil {
+		panic(err)
+	}
+	chatPersonas, err := storage.Open(dataDir, "personas", map[int64]string{})
+	if err != nil {
+		panic(err)
+	}
+	personas, err := chat.LoadPersonas(chatPersonas)
+	if err != nil {
+		panic(fmt.Errorf("fatal error personas: %w", err))
+	}
+
+	facts, err := storage.Open(dataDir, "memories", map[int64][]memory.Fact{})
+	if err != nil {
+		panic(err)
+	}
+
+	chatHistory, err := storage.Open(dataDir, "history", map[int64]*history.Chat{})
+	if err != nil {
+		panic(err)
+	}
+	embedder := history.NewGeminiEmbedder(aiClient, viper.GetString("history.embedding_model"), viper.GetInt32("history.dimensions"))
+	historyIndex, err := history.NewIndex(chatHistory, filepath.Join(dataDir, "history"), embedder, viper.GetInt("history.max_messages"))
+	if err != nil {
+		panic(fmt.Errorf("fatal error history: %w", err))
+	}
+
+	services := &chat.Services{
+		AI:       aiClient,
+		Settings: chat.NewSettings(chatSettings, personas),
+		Personas: personas,
+		Location: location,
+		Memory:   memory.New(facts),
+		History:  historyIndex,
+		Chats:    chat.CreateChatStore(viper.GetInt("bot.max_history")),
+		GameNight: gamenight.NewPlanner(gameNights, location,
+			viper.GetDuration("gamenight.close_before"), viper.GetDuration("gamenight.remind_before"), languages),
+		Translator:    chat.NewTranslator(translatorRules),
+		Glossary:      chat.NewGlossary(glossary),
+		Recent:        chat.NewRecentMessages(50),
+		Behaviors:     chat.NewBehaviors(behaviorChances, location),
+		Participation: chat.NewParticipation(participation, location),
+		Languages:     languages,
 	}
 
-	chat_store := chat.CreateChatStore(viper.GetInt("bot.max_history"))
 	opts := []bot.Option{
 		bot.WithDefaultHandler(func(ctx context.Context, bot *bot.Bot, update *models.Update) {
-			chat.Handler(ctx, bot, update, aiClient, config, chat_store)
+			chat.Handler(ctx, bot, update, services)
+		}),
+		bot.WithCallbackQueryDataHandler(capabilities.DotaPickCallbackPrefix, bot.MatchTypePrefix, func(ctx context.Context, bot *bot.Bot, update *models.Update) {
+			chat.DotaPickHandler(ctx, bot, update, services)
+		}),
+		bot.WithCallbackQueryDataHandler(chat.ContinueCallbackPrefix, bot.MatchTypePrefix, func(ctx context.Context, bot *bot.Bot, update *models.Update) {
+			chat.ContinueHandler(ctx, bot, update, services)
+		}),
+		bot.WithMessageTextHandler("/gamenight", bot.MatchTypePrefix, func(ctx context.Context, bot *bot.Bot, update *models.Update) {
+			chat.GameNightCommand(ctx, bot, update, services)
+		}),
+		bot.WithMessageTextHandler("/translator", bot.MatchTypePrefix, func(ctx context.Context, bot *bot.Bot, update *models.Update) {
+			chat.TranslatorCommand(ctx, bot, update, services)
+		}),
+		bot.WithMessageTextHandler("/glossary", bot.MatchTypePrefix, func(ctx context.Context, bot *bot.Bot, update *models.Update) {
+			chat.GlossaryCommand(ctx, bot, update, services)
+		}),
+		bot.WithMessageTextHandler("/behaviors", bot.MatchTypePrefix, func(ctx context.Context, bot *bot.Bot, update *models.Update) {
+			chat.BehaviorsCommand(ctx, bot, update, services)
+		}),
+		bot.WithMessageTextHandler("/participation", bot.MatchTypePrefix, func(ctx context.Context, bot *bot.Bot, update *models.Update) {
+			chat.ParticipationCommand(ctx, bot, update, services)
+		}),
+		bot.WithMessageTextHandler("/language", bot.MatchTypePrefix, func(ctx context.Context, bot *bot.Bot, update *models.Update) {
+			chat.LanguageCommand(ctx, bot, update, services)
+		}),
+		bot.WithMessageTextHandler("/settings", bot.MatchTypePrefix, func(ctx context.Context, bot *bot.Bot, update *models.Update) {
+			chat.SettingsCommand(ctx, bot, update, services)
+		}),
+		bot.WithMessageTextHandler("/persona", bot.MatchTypePrefix, func(ctx context.Context, bot *bot.Bot, update *models.Update) {
+			chat.PersonaCommand(ctx, bot, update, services)
+		}),
+		bot.WithMessageTextHandler("/memories", bot.MatchTypePrefix, func(ctx context.Context, bot *bot.Bot, update *models.Update) {
+			chat.MemoriesCommand(ctx, bot, update, services)
+		}),
+		bot.WithMessageTextHandler("/history", bot.MatchTypePrefix, func(ctx context.Context, bot *bot.Bot, update *models.Update) {
+			chat.HistoryCommand(ctx, bot, update, services)
 		}),
 	}
 	b, err := bot.New(viper.GetString("keys.telegram"), opts...)
@@ -53,7 +197,18 @@ func main() {
 		panic(err)
 	}
 
+	go services.GameNight.Run(ctx, b)
+
+	historyDone := make(chan struct{})
+	go func() {
+		services.History.Run(ctx)
+		close(historyDone)
+	}()
+
 	// Start bot
 	fmt.Println("Bot started..")
 	b.Start(ctx)
+
+	// Let the queued messages be saved
+	<-historyDone
 }
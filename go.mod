@@ -5,6 +5,7 @@ go 1.24.2
 require (
 	github.com/go-telegram/bot v1.14.2
 	github.com/spf13/viper v1.20.1
+	golang.org/x/image v0.25.0
 	google.golang.org/genai v1.7.0
 )
 
@@ -1,6 +1,10 @@
 package capabilities
 
-import "google.golang.org/genai"
+import (
+	"slices"
+
+	"google.golang.org/genai"
+)
 
 type CallResponse = map[string]any
 
@@ -11,8 +15,59 @@ var Tools = []*genai.Tool{
 			&DotaPlayerAccountDeclaration,
 			&DotaPlayerMatchesDeclaration,
 			&DotaHeroesDeclaration,
+			&DotaPlayerWinLossDeclaration,
+			&DotaPlayerHeroesDeclaration,
+			&DotaPlayerPeersDeclaration,
+			&DotaPlayerRecordsDeclaration,
+			&DotaComparePlayersDeclaration,
+			&DotaPlayerChartDeclaration,
+			&DotaRequestParseDeclaration,
+			&DotaSearchPlayerDeclaration,
+			&SteamIdConvertDeclaration,
+			&DotaMatchHighlightsDeclaration,
+			&DotaRandomHeroDeclaration,
+			&DotaDraftHelperDeclaration,
+			&StartGameNightDeclaration,
+			&RememberFactDeclaration,
+			&RecallFactsDeclaration,
+			&ForgetFactDeclaration,
+			&SearchChatHistoryDeclaration,
 			&UnixTimestampDeclaration,
 			&MyIdDeclaration,
 		},
 	},
 }
+
+// ToolNames returns the names of every capability, in declaration order.
+func ToolNames() []string {
+	names := []string{}
+	for _, tool := range Tools {
+		for _, declaration := range tool.FunctionDeclarations {
+			names = append(names, declaration.Name)
+		}
+	}
+
+	return names
+}
+
+// FilterTools returns Tools with only the named capabilities, or every
+// capability when names is empty.
+func FilterTools(names []string) []*genai.Tool {
+	if len(names) == 0 {
+		return Tools
+	}
+
+	declarations := []*genai.FunctionDeclaration{}
+	for _, tool := range Tools {
+		for _, declaration := range tool.FunctionDeclarations {
+			if slices.Contains(names, declaration.Name) {
+				declarations = append(declarations, declaration)
+			}
+		}
+	}
+	if len(declarations) == 0 {
+		return nil
+	}
+
+	return []*genai.Tool{{FunctionDeclarations: declarations}}
+}
@@ -0,0 +1,59 @@
+package capabilities
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/victormamede/benebott/internal/history"
+	"google.golang.org/genai"
+)
+
+var SearchChatHistoryDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
+	Name:        "search_chat_history",
+	Description: "Searches the past messages of this chat by meaning, e.g. to find when the group talked about something. Returns the closest messages with their dates and links. Only works in chats where history search was enabled.",
+	Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"query": &genai.Schema{
+				Type:        genai.TypeString,
+				Description: "What to look for, described in a few words",
+			},
+			"limit": &genai.Schema{
+				Type:        genai.TypeInteger,
+				Description: "How many messages to return, 5 by default and at most 20",
+			},
+		},
+		Required: []string{"query"},
+	},
+}
+
+// SearchChatHistory returns the limit messages of the chat closest in
+// meaning to query, with their dates in location.
+func SearchChatHistory(ctx context.Context, index *history.Index, chatID int64, location *time.Location, query string, limit int) CallResponse {
+	log.Println("Searching chat history for", query)
+
+	callResponse := map[string]any{}
+	results, err := index.Search(ctx, chatID, query, min(max(limit, 1), 20))
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	messages := []any{}
+	for _, result := range results {
+		item := map[string]any{
+			"author":     result.Author,
+			"text":       result.Text,
+			"date":       result.Date.In(location).Format("2006-01-02 15:04"),
+			"similarity": result.Score,
+		}
+		if result.Link != "" {
+			item["link"] = result.Link
+		}
+		messages = append(messages, item)
+	}
+
+	callResponse["messages"] = messages
+	return callResponse
+}
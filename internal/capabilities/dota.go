@@ -1,10 +1,12 @@
 package capabilities
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"google.golang.org/genai"
@@ -25,47 +27,162 @@ var DotaPlayerAccountDeclaration genai.FunctionDeclaration = genai.FunctionDecla
 type DotaHero struct {
 	Name          string
 	LocalizedName string
+	PrimaryAttr   string
+	Roles         []string
 }
 
-func DotaPlayerAccount(playerId string) CallResponse {
-	fmt.Println("Getting dota account for player", playerId)
+func DotaPlayerAccount(ctx context.Context, playerId string) CallResponse {
+	log.Println("Getting dota account for player", playerId)
 
-	response, err := http.Get(fmt.Sprintf("https://api.opendota.com/api/players/%s", playerId))
 	callResponse := map[string]any{}
-
-	if err != nil {
-		callResponse["error"] = err.Error()
-		return callResponse
-	}
-
-	responseData, err := io.ReadAll(response.Body)
+	playerId, err := ResolveDotaAccount(playerId)
 	if err != nil {
 		callResponse["error"] = err.Error()
 		return callResponse
 	}
 
-	err = json.Unmarshal(responseData, &callResponse)
+	player, err := dotaFallback(func(provider DotaProvider) (DotaPlayer, error) {
+		return provider.Player(ctx, playerId)
+	})
 	if err != nil {
 		callResponse["error"] = err.Error()
 		return callResponse
 	}
 
-	return callResponse
+	return player.payload()
 }
 
 var DotaPlayerMatchesDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
 	Name:        "dota_player_matches",
-	Description: "Gets match information for a dota player by id.",
+	Description: "Gets match information for a dota player by id. Optional filters can be combined to narrow down the matches.",
 	Parameters: &genai.Schema{
 		Type: genai.TypeObject,
-		Properties: map[string]*genai.Schema{
-			"playerId": &genai.Schema{Type: genai.TypeString, Description: "The player ID"},
-			"limit":    &genai.Schema{Type: genai.TypeInteger, Description: "The number of matches to fetch, not higher than 10"},
-		},
-		Required: []string{"playerId", "limit"},
+		Properties: dotaFilterProperties(map[string]*genai.Schema{
+			"playerId": &genai.Schema{Type: genai.TypeString, Description: "The player account ID, SteamID or profile URL"},
+			"limit":    &genai.Schema{Type: genai.TypeInteger, Description: fmt.Sprintf("The number of matches to fetch, not higher than %d", dotaMaxMatches)},
+		}),
+		Required: []string{"playerId"},
 	},
 }
 
+// dotaFilterProperties adds the optional match filters understood by
+// ParseDotaMatchFilters to a declaration's properties.
+func dotaFilterProperties(properties map[string]*genai.Schema) map[string]*genai.Schema {
+	properties["hero"] = &genai.Schema{Type: genai.TypeString, Description: "Only matches played with this hero (name or id)"}
+	properties["result"] = &genai.Schema{Type: genai.TypeString, Enum: []string{"win", "loss"}, Description: "Only won or lost matches"}
+	properties["days"] = &genai.Schema{Type: genai.TypeInteger, Description: "Only matches played in the last N days"}
+	properties["gameMode"] = &genai.Schema{Type: genai.TypeString, Description: "Only matches in this game mode, e.g. All Pick, Turbo, Captains Mode"}
+	properties["lobbyType"] = &genai.Schema{Type: genai.TypeString, Description: "Only matches in this lobby type, e.g. Normal, Ranked"}
+	properties["withPlayer"] = &genai.Schema{Type: genai.TypeString, Description: "Only matches played together with this account ID"}
+
+	return properties
+}
+
+const (
+	dotaDefaultMatches = 10
+	dotaMaxMatches     = 20
+	dotaMaxDays        = 365
+)
+
+type DotaMatchFilters struct {
+	Limit      int
+	HeroId     int
+	Win        *bool
+	Days       int
+	GameMode   int
+	LobbyType  int
+	WithPlayer string
+}
+
+// ParseDotaMatchFilters reads the optional filters of DotaPlayerMatchesDeclaration
+// from the model arguments, clamping them to values OpenDota handles well.
+func ParseDotaMatchFilters(args map[string]any) (DotaMatchFilters, error) {
+	filters := DotaMatchFilters{GameMode: -1, LobbyType: -1}
+
+	if limit, ok := args["limit"].(float64); ok {
+		filters.Limit = min(max(int(limit), 1), dotaMaxMatches)
+	}
+
+	if hero, ok := args["hero"].(string); ok && hero != "" {
+		id, ok := FindDotaHero(hero)
+		if !ok {
+			return filters, fmt.Errorf("unknown hero %q", hero)
+		}
+		filters.HeroId = id
+	}
+
+	switch args["result"] {
+	case "win":
+		win := true
+		filters.Win = &win
+	case "loss":
+		win := false
+		filters.Win = &win
+	}
+
+	if days, ok := args["days"].(float64); ok && days > 0 {
+		filters.Days = min(int(days), dotaMaxDays)
+	}
+
+	if mode, ok := args["gameMode"].(string); ok && mode != "" {
+		id, ok := findDotaConstant(dotaGameModes, mode)
+		if !ok {
+			return filters, fmt.Errorf("unknown game mode %q", mode)
+		}
+		filters.GameMode = id
+	}
+
+	if lobby, ok := args["lobbyType"].(string); ok && lobby != "" {
+		id, ok := findDotaConstant(dotaLobbyTypes, lobby)
+		if !ok {
+			return filters, fmt.Errorf("unknown lobby type %q", lobby)
+		}
+		filters.LobbyType = id
+	}
+
+	if with, ok := args["withPlayer"].(string); ok && with != "" {
+		account, err := ResolveDotaAccount(with)
+		if err != nil {
+			return filters, err
+		}
+		filters.WithPlayer = account
+	}
+
+	return filters, nil
+}
+
+func (f DotaMatchFilters) query() url.Values {
+	query := url.Values{}
+
+	if f.Limit != 0 {
+		query.Set("limit", strconv.Itoa(f.Limit))
+	}
+	if f.HeroId != 0 {
+		query.Set("hero_id", strconv.Itoa(f.HeroId))
+	}
+	if f.Win != nil {
+		if *f.Win {
+			query.Set("win", "1")
+		} else {
+			query.Set("win", "0")
+		}
+	}
+	if f.Days != 0 {
+		query.Set("date", strconv.Itoa(f.Days))
+	}
+	if f.GameMode >= 0 {
+		query.Set("game_mode", strconv.Itoa(f.GameMode))
+	}
+	if f.LobbyType >= 0 {
+		query.Set("lobby_type", strconv.Itoa(f.LobbyType))
+	}
+	if f.WithPlayer != "" {
+		query.Set("included_account_id", f.WithPlayer)
+	}
+
+	return query
+}
+
 type DotaPlayerMatchResponse struct {
 	MatchId      float64 `json:"match_id"`
 	PlayerSlot   float64 `json:"player_slot"`
@@ -81,27 +198,19 @@ type DotaPlayerMatchResponse struct {
 	LeaverStatus float64 `json:"leaver_status"`
 	PartySize    float64 `json:"party_size"`
 	HeroVariant  float64 `json:"hero_variant"`
+	GameMode     float64 `json:"game_mode"`
+	LobbyType    float64 `json:"lobby_type"`
 }
 
-func DotaPlayerMatches(playerId string, limit int) CallResponse {
-	fmt.Println("Getting dota matches for player", playerId, "limit", limit)
-
-	response, err := http.Get(fmt.Sprintf("https://api.opendota.com/api/players/%s/matches?limit=%d", playerId, limit))
+func DotaPlayerMatches(ctx context.Context, playerId string, filters DotaMatchFilters) CallResponse {
 	callResponse := map[string]any{}
-
-	if err != nil {
-		callResponse["error"] = err.Error()
-		return callResponse
-	}
-
-	responseData, err := io.ReadAll(response.Body)
+	playerId, err := ResolveDotaAccount(playerId)
 	if err != nil {
 		callResponse["error"] = err.Error()
 		return callResponse
 	}
 
-	items := []DotaPlayerMatchResponse{}
-	err = json.Unmarshal(responseData, &items)
+	items, err := fetchDotaPlayerMatches(ctx, playerId, filters)
 	if err != nil {
 		callResponse["error"] = err.Error()
 		return callResponse
@@ -109,34 +218,58 @@ func DotaPlayerMatches(playerId string, limit int) CallResponse {
 
 	parsedItems := []any{}
 	for _, item := range items {
-		team := "radiant"
-		won := item.RadiantWin
-		if item.PlayerSlot >= 128 {
-			team = "dire"
-			won = !item.RadiantWin
-		}
-		startTime := time.Unix(int64(item.StartTime), 0)
-		playerLeft := item.LeaverStatus != 0
-
-		parsedItems = append(parsedItems, map[string]any{
-			"match_id":         item.MatchId,
-			"team":             team,
-			"won":              won,
-			"duration_minutes": item.Duration / 60.0,
-			"hero":             heroes[uint8(item.HeroId)].Name,
-			"start_time":       startTime.Format(time.RFC3339),
-			"kills":            item.Kills,
-			"deaths":           item.Deaths,
-			"assists":          item.Assists,
-			"did_player_leave": playerLeft,
-			"party_size":       item.PartySize,
-		})
+		parsedItems = append(parsedItems, item.summary())
 	}
 
 	callResponse["items"] = parsedItems
 	return callResponse
 }
 
+func fetchDotaPlayerMatches(ctx context.Context, playerId string, filters DotaMatchFilters) ([]DotaPlayerMatchResponse, error) {
+	if filters.Limit == 0 {
+		filters.Limit = dotaDefaultMatches
+	}
+
+	log.Println("Getting dota matches for player", playerId, "filters", filters.query().Encode())
+
+	return dotaFallback(func(provider DotaProvider) ([]DotaPlayerMatchResponse, error) {
+		return provider.Matches(ctx, playerId, filters)
+	})
+}
+
+func (item DotaPlayerMatchResponse) won() bool {
+	if item.PlayerSlot >= 128 {
+		return !item.RadiantWin
+	}
+
+	return item.RadiantWin
+}
+
+func (item DotaPlayerMatchResponse) summary() map[string]any {
+	team := "radiant"
+	if item.PlayerSlot >= 128 {
+		team = "dire"
+	}
+	startTime := time.Unix(int64(item.StartTime), 0)
+	playerLeft := item.LeaverStatus != 0
+
+	return map[string]any{
+		"match_id":         item.MatchId,
+		"team":             team,
+		"won":              item.won(),
+		"duration_minutes": item.Duration / 60.0,
+		"hero":             heroes[int(item.HeroId)].Name,
+		"start_time":       startTime.Format(time.RFC3339),
+		"kills":            item.Kills,
+		"deaths":           item.Deaths,
+		"assists":          item.Assists,
+		"did_player_leave": playerLeft,
+		"party_size":       item.PartySize,
+		"game_mode":        DotaGameMode(int(item.GameMode)),
+		"lobby_type":       DotaLobbyType(int(item.LobbyType)),
+	}
+}
+
 var DotaHeroesDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
 	Name:        "dota_heroes",
 	Description: `Gets information for each dota hero and their id. `,
@@ -146,537 +279,815 @@ var DotaHeroesDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
 	},
 }
 
-func DotaHeroes() CallResponse {
-	fmt.Println("Getting dota heroes")
+func DotaHeroes(ctx context.Context) CallResponse {
+	log.Println("Getting dota heroes")
 
-	response, err := http.Get("https://api.opendota.com/api/heroes")
 	callResponse := map[string]any{}
 
+	items, err := dotaFallback(func(provider DotaProvider) ([]DotaHeroInfo, error) {
+		return provider.Heroes(ctx)
+	})
 	if err != nil {
 		callResponse["error"] = err.Error()
 		return callResponse
 	}
 
-	responseData, err := io.ReadAll(response.Body)
-	if err != nil {
-		callResponse["error"] = err.Error()
-		return callResponse
+	parsedItems := []any{}
+	for _, item := range items {
+		parsedItems = append(parsedItems, map[string]any{
+			"id":           item.Id,
+			"name":         item.LocalizedName,
+			"primary_attr": item.PrimaryAttr,
+			"roles":        item.Roles,
+		})
 	}
 
-	items := []any{}
-	err = json.Unmarshal(responseData, &items)
-	if err != nil {
-		callResponse["error"] = err.Error()
-		return callResponse
+	callResponse["heroes"] = parsedItems
+	return callResponse
+}
+
+func dotaHeroName(id float64) string {
+	hero, ok := heroes[int(id)]
+	if !ok {
+		return fmt.Sprintf("Unknown (%d)", int(id))
 	}
 
-	callResponse["heroes"] = items
-	return callResponse
+	return hero.LocalizedName
+}
+
+// FindDotaHero resolves a hero by id, localized name or internal name.
+func FindDotaHero(name string) (int, bool) {
+	if id, err := strconv.Atoi(name); err == nil {
+		_, ok := heroes[id]
+		return id, ok
+	}
+
+	for id, hero := range heroes {
+		if strings.EqualFold(hero.LocalizedName, name) ||
+			strings.EqualFold(hero.Name, name) ||
+			strings.EqualFold(hero.Name, "npc_dota_hero_"+name) {
+			return id, true
+		}
+	}
+
+	return 0, false
 }
 
-var heroes map[uint8]DotaHero = map[uint8]DotaHero{
+var heroes map[int]DotaHero = map[int]DotaHero{
 	1: DotaHero{
 		Name:          "npc_dota_hero_antimage",
 		LocalizedName: "Anti-Mage",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Escape", "Nuker"},
 	},
 	2: DotaHero{
 		Name:          "npc_dota_hero_axe",
 		LocalizedName: "Axe",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Initiator", "Durable", "Disabler", "Carry"},
 	},
 	3: DotaHero{
 		Name:          "npc_dota_hero_bane",
 		LocalizedName: "Bane",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Support", "Disabler", "Nuker", "Durable"},
 	},
 	4: DotaHero{
 		Name:          "npc_dota_hero_bloodseeker",
 		LocalizedName: "Bloodseeker",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Disabler", "Nuker", "Initiator"},
 	},
 	5: DotaHero{
 		Name:          "npc_dota_hero_crystal_maiden",
 		LocalizedName: "Crystal Maiden",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Support", "Disabler", "Nuker"},
 	},
 	6: DotaHero{
 		Name:          "npc_dota_hero_drow_ranger",
 		LocalizedName: "Drow Ranger",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Disabler", "Pusher"},
 	},
 	7: DotaHero{
 		Name:          "npc_dota_hero_earthshaker",
 		LocalizedName: "Earthshaker",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Support", "Initiator", "Disabler", "Nuker"},
 	},
 	8: DotaHero{
 		Name:          "npc_dota_hero_juggernaut",
 		LocalizedName: "Juggernaut",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Pusher", "Escape"},
 	},
 	9: DotaHero{
 		Name:          "npc_dota_hero_mirana",
 		LocalizedName: "Mirana",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Carry", "Support", "Escape", "Nuker", "Disabler"},
 	},
 	10: DotaHero{
 		Name:          "npc_dota_hero_morphling",
 		LocalizedName: "Morphling",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Escape", "Durable", "Nuker", "Disabler"},
 	},
 	11: DotaHero{
 		Name:          "npc_dota_hero_nevermore",
 		LocalizedName: "Shadow Fiend",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Nuker"},
 	},
 	12: DotaHero{
 		Name:          "npc_dota_hero_phantom_lancer",
 		LocalizedName: "Phantom Lancer",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Escape", "Pusher", "Nuker"},
 	},
 	13: DotaHero{
 		Name:          "npc_dota_hero_puck",
 		LocalizedName: "Puck",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Initiator", "Disabler", "Escape", "Nuker"},
 	},
 	14: DotaHero{
 		Name:          "npc_dota_hero_pudge",
 		LocalizedName: "Pudge",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Disabler", "Initiator", "Durable", "Nuker"},
 	},
 	15: DotaHero{
 		Name:          "npc_dota_hero_razor",
 		LocalizedName: "Razor",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Durable", "Nuker", "Pusher"},
 	},
 	16: DotaHero{
 		Name:          "npc_dota_hero_sand_king",
 		LocalizedName: "Sand King",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Initiator", "Disabler", "Support", "Nuker", "Escape"},
 	},
 	17: DotaHero{
 		Name:          "npc_dota_hero_storm_spirit",
 		LocalizedName: "Storm Spirit",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Carry", "Escape", "Nuker", "Initiator", "Disabler"},
 	},
 	18: DotaHero{
 		Name:          "npc_dota_hero_sven",
 		LocalizedName: "Sven",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Carry", "Disabler", "Initiator", "Durable", "Nuker"},
 	},
 	19: DotaHero{
 		Name:          "npc_dota_hero_tiny",
 		LocalizedName: "Tiny",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Carry", "Nuker", "Pusher", "Initiator", "Durable", "Disabler"},
 	},
 	20: DotaHero{
 		Name:          "npc_dota_hero_vengefulspirit",
 		LocalizedName: "Vengeful Spirit",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Support", "Initiator", "Disabler", "Nuker", "Escape"},
 	},
 	21: DotaHero{
 		Name:          "npc_dota_hero_windrunner",
 		LocalizedName: "Windranger",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Carry", "Support", "Disabler", "Escape", "Nuker"},
 	},
 	22: DotaHero{
 		Name:          "npc_dota_hero_zuus",
 		LocalizedName: "Zeus",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Nuker", "Carry"},
 	},
 	23: DotaHero{
 		Name:          "npc_dota_hero_kunkka",
 		LocalizedName: "Kunkka",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Carry", "Support", "Disabler", "Initiator", "Durable", "Nuker"},
 	},
 	25: DotaHero{
 		Name:          "npc_dota_hero_lina",
 		LocalizedName: "Lina",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Support", "Carry", "Nuker", "Disabler"},
 	},
 	26: DotaHero{
 		Name:          "npc_dota_hero_lion",
 		LocalizedName: "Lion",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Support", "Disabler", "Nuker", "Initiator"},
 	},
 	27: DotaHero{
 		Name:          "npc_dota_hero_shadow_shaman",
 		LocalizedName: "Shadow Shaman",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Support", "Pusher", "Disabler", "Nuker", "Initiator"},
 	},
 	28: DotaHero{
 		Name:          "npc_dota_hero_slardar",
 		LocalizedName: "Slardar",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Carry", "Durable", "Initiator", "Disabler", "Escape"},
 	},
 	29: DotaHero{
 		Name:          "npc_dota_hero_tidehunter",
 		LocalizedName: "Tidehunter",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Initiator", "Durable", "Disabler", "Nuker", "Carry"},
 	},
 	30: DotaHero{
 		Name:          "npc_dota_hero_witch_doctor",
 		LocalizedName: "Witch Doctor",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Support", "Nuker", "Disabler"},
 	},
 	31: DotaHero{
 		Name:          "npc_dota_hero_lich",
 		LocalizedName: "Lich",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Support", "Nuker"},
 	},
 	32: DotaHero{
 		Name:          "npc_dota_hero_riki",
 		LocalizedName: "Riki",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Escape", "Disabler"},
 	},
 	33: DotaHero{
 		Name:          "npc_dota_hero_enigma",
 		LocalizedName: "Enigma",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Disabler", "Initiator", "Pusher"},
 	},
 	34: DotaHero{
 		Name:          "npc_dota_hero_tinker",
 		LocalizedName: "Tinker",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Carry", "Nuker", "Pusher"},
 	},
 	35: DotaHero{
 		Name:          "npc_dota_hero_sniper",
 		LocalizedName: "Sniper",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Nuker"},
 	},
 	36: DotaHero{
 		Name:          "npc_dota_hero_necrolyte",
 		LocalizedName: "Necrophos",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Carry", "Nuker", "Durable", "Disabler"},
 	},
 	37: DotaHero{
 		Name:          "npc_dota_hero_warlock",
 		LocalizedName: "Warlock",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Support", "Initiator", "Disabler"},
 	},
 	38: DotaHero{
 		Name:          "npc_dota_hero_beastmaster",
 		LocalizedName: "Beastmaster",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Initiator", "Disabler", "Durable", "Nuker"},
 	},
 	39: DotaHero{
 		Name:          "npc_dota_hero_queenofpain",
 		LocalizedName: "Queen of Pain",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Carry", "Nuker", "Escape"},
 	},
 	40: DotaHero{
 		Name:          "npc_dota_hero_venomancer",
 		LocalizedName: "Venomancer",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Support", "Nuker", "Initiator", "Pusher", "Disabler"},
 	},
 	41: DotaHero{
 		Name:          "npc_dota_hero_faceless_void",
 		LocalizedName: "Faceless Void",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Initiator", "Disabler", "Escape", "Durable"},
 	},
 	42: DotaHero{
 		Name:          "npc_dota_hero_skeleton_king",
 		LocalizedName: "Wraith King",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Carry", "Support", "Durable", "Disabler", "Initiator"},
 	},
 	43: DotaHero{
 		Name:          "npc_dota_hero_death_prophet",
 		LocalizedName: "Death Prophet",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Carry", "Pusher", "Nuker", "Disabler"},
 	},
 	44: DotaHero{
 		Name:          "npc_dota_hero_phantom_assassin",
 		LocalizedName: "Phantom Assassin",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Escape"},
 	},
 	45: DotaHero{
 		Name:          "npc_dota_hero_pugna",
 		LocalizedName: "Pugna",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Nuker", "Pusher"},
 	},
 	46: DotaHero{
 		Name:          "npc_dota_hero_templar_assassin",
 		LocalizedName: "Templar Assassin",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Escape"},
 	},
 	47: DotaHero{
 		Name:          "npc_dota_hero_viper",
 		LocalizedName: "Viper",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Durable", "Initiator", "Disabler"},
 	},
 	48: DotaHero{
 		Name:          "npc_dota_hero_luna",
 		LocalizedName: "Luna",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Nuker", "Pusher"},
 	},
 	49: DotaHero{
 		Name:          "npc_dota_hero_dragon_knight",
 		LocalizedName: "Dragon Knight",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Carry", "Pusher", "Durable", "Disabler", "Initiator", "Nuker"},
 	},
 	50: DotaHero{
 		Name:          "npc_dota_hero_dazzle",
 		LocalizedName: "Dazzle",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Support", "Nuker", "Disabler"},
 	},
 	51: DotaHero{
 		Name:          "npc_dota_hero_rattletrap",
 		LocalizedName: "Clockwerk",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Initiator", "Disabler", "Durable", "Nuker"},
 	},
 	52: DotaHero{
 		Name:          "npc_dota_hero_leshrac",
 		LocalizedName: "Leshrac",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Carry", "Support", "Nuker", "Pusher", "Disabler"},
 	},
 	53: DotaHero{
 		Name:          "npc_dota_hero_furion",
 		LocalizedName: "Nature's Prophet",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Carry", "Pusher", "Escape", "Nuker"},
 	},
 	54: DotaHero{
 		Name:          "npc_dota_hero_life_stealer",
 		LocalizedName: "Lifestealer",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Carry", "Durable", "Escape", "Disabler"},
 	},
 	55: DotaHero{
 		Name:          "npc_dota_hero_dark_seer",
 		LocalizedName: "Dark Seer",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Initiator", "Escape", "Disabler"},
 	},
 	56: DotaHero{
 		Name:          "npc_dota_hero_clinkz",
 		LocalizedName: "Clinkz",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Escape", "Pusher"},
 	},
 	57: DotaHero{
 		Name:          "npc_dota_hero_omniknight",
 		LocalizedName: "Omniknight",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Support", "Durable", "Nuker"},
 	},
 	58: DotaHero{
 		Name:          "npc_dota_hero_enchantress",
 		LocalizedName: "Enchantress",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Support", "Pusher", "Durable", "Disabler"},
 	},
 	59: DotaHero{
 		Name:          "npc_dota_hero_huskar",
 		LocalizedName: "Huskar",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Carry", "Durable", "Initiator"},
 	},
 	60: DotaHero{
 		Name:          "npc_dota_hero_night_stalker",
 		LocalizedName: "Night Stalker",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Carry", "Initiator", "Durable", "Disabler", "Nuker"},
 	},
 	61: DotaHero{
 		Name:          "npc_dota_hero_broodmother",
 		LocalizedName: "Broodmother",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Carry", "Pusher", "Escape", "Nuker"},
 	},
 	62: DotaHero{
 		Name:          "npc_dota_hero_bounty_hunter",
 		LocalizedName: "Bounty Hunter",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Escape", "Nuker"},
 	},
 	63: DotaHero{
 		Name:          "npc_dota_hero_weaver",
 		LocalizedName: "Weaver",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Escape"},
 	},
 	64: DotaHero{
 		Name:          "npc_dota_hero_jakiro",
 		LocalizedName: "Jakiro",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Support", "Nuker", "Pusher", "Disabler"},
 	},
 	65: DotaHero{
 		Name:          "npc_dota_hero_batrider",
 		LocalizedName: "Batrider",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Initiator", "Disabler", "Escape"},
 	},
 	66: DotaHero{
 		Name:          "npc_dota_hero_chen",
 		LocalizedName: "Chen",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Support", "Pusher"},
 	},
 	67: DotaHero{
 		Name:          "npc_dota_hero_spectre",
 		LocalizedName: "Spectre",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Durable", "Escape"},
 	},
 	68: DotaHero{
 		Name:          "npc_dota_hero_ancient_apparition",
 		LocalizedName: "Ancient Apparition",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Support", "Disabler", "Nuker"},
 	},
 	69: DotaHero{
 		Name:          "npc_dota_hero_doom_bringer",
 		LocalizedName: "Doom",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Carry", "Disabler", "Initiator", "Durable", "Nuker"},
 	},
 	70: DotaHero{
 		Name:          "npc_dota_hero_ursa",
 		LocalizedName: "Ursa",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Durable", "Disabler"},
 	},
 	71: DotaHero{
 		Name:          "npc_dota_hero_spirit_breaker",
 		LocalizedName: "Spirit Breaker",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Carry", "Initiator", "Disabler", "Durable", "Escape"},
 	},
 	72: DotaHero{
 		Name:          "npc_dota_hero_gyrocopter",
 		LocalizedName: "Gyrocopter",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Nuker", "Disabler"},
 	},
 	73: DotaHero{
 		Name:          "npc_dota_hero_alchemist",
 		LocalizedName: "Alchemist",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Support", "Carry", "Durable", "Disabler", "Initiator", "Nuker"},
 	},
 	74: DotaHero{
 		Name:          "npc_dota_hero_invoker",
 		LocalizedName: "Invoker",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Carry", "Nuker", "Disabler", "Escape", "Pusher"},
 	},
 	75: DotaHero{
 		Name:          "npc_dota_hero_silencer",
 		LocalizedName: "Silencer",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Carry", "Support", "Disabler", "Initiator", "Nuker"},
 	},
 	76: DotaHero{
 		Name:          "npc_dota_hero_obsidian_destroyer",
 		LocalizedName: "Outworld Destroyer",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Carry", "Nuker", "Disabler"},
 	},
 	77: DotaHero{
 		Name:          "npc_dota_hero_lycan",
 		LocalizedName: "Lycan",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Carry", "Pusher", "Durable", "Escape"},
 	},
 	78: DotaHero{
 		Name:          "npc_dota_hero_brewmaster",
 		LocalizedName: "Brewmaster",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Carry", "Initiator", "Durable", "Disabler", "Nuker"},
 	},
 	79: DotaHero{
 		Name:          "npc_dota_hero_shadow_demon",
 		LocalizedName: "Shadow Demon",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Support", "Disabler", "Initiator", "Nuker"},
 	},
 	80: DotaHero{
 		Name:          "npc_dota_hero_lone_druid",
 		LocalizedName: "Lone Druid",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Pusher", "Durable"},
 	},
 	81: DotaHero{
 		Name:          "npc_dota_hero_chaos_knight",
 		LocalizedName: "Chaos Knight",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Carry", "Disabler", "Durable", "Pusher", "Initiator"},
 	},
 	82: DotaHero{
 		Name:          "npc_dota_hero_meepo",
 		LocalizedName: "Meepo",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Escape", "Nuker", "Disabler", "Initiator", "Pusher"},
 	},
 	83: DotaHero{
 		Name:          "npc_dota_hero_treant",
 		LocalizedName: "Treant Protector",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Support", "Initiator", "Durable", "Disabler", "Escape"},
 	},
 	84: DotaHero{
 		Name:          "npc_dota_hero_ogre_magi",
 		LocalizedName: "Ogre Magi",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Support", "Nuker", "Disabler", "Durable", "Initiator"},
 	},
 	85: DotaHero{
 		Name:          "npc_dota_hero_undying",
 		LocalizedName: "Undying",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Support", "Durable", "Disabler", "Nuker"},
 	},
 	86: DotaHero{
 		Name:          "npc_dota_hero_rubick",
 		LocalizedName: "Rubick",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Support", "Disabler", "Nuker"},
 	},
 	87: DotaHero{
 		Name:          "npc_dota_hero_disruptor",
 		LocalizedName: "Disruptor",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Support", "Disabler", "Nuker", "Initiator"},
 	},
 	88: DotaHero{
 		Name:          "npc_dota_hero_nyx_assassin",
 		LocalizedName: "Nyx Assassin",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Disabler", "Nuker", "Initiator", "Escape"},
 	},
 	89: DotaHero{
 		Name:          "npc_dota_hero_naga_siren",
 		LocalizedName: "Naga Siren",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Support", "Pusher", "Disabler", "Initiator", "Escape"},
 	},
 	90: DotaHero{
 		Name:          "npc_dota_hero_keeper_of_the_light",
 		LocalizedName: "Keeper of the Light",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Support", "Nuker", "Disabler"},
 	},
 	91: DotaHero{
 		Name:          "npc_dota_hero_wisp",
 		LocalizedName: "Io",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Support", "Escape", "Nuker"},
 	},
 	92: DotaHero{
 		Name:          "npc_dota_hero_visage",
 		LocalizedName: "Visage",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Support", "Nuker", "Durable", "Disabler", "Pusher"},
 	},
 	93: DotaHero{
 		Name:          "npc_dota_hero_slark",
 		LocalizedName: "Slark",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Escape", "Disabler", "Nuker"},
 	},
 	94: DotaHero{
 		Name:          "npc_dota_hero_medusa",
 		LocalizedName: "Medusa",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Disabler", "Durable"},
 	},
 	95: DotaHero{
 		Name:          "npc_dota_hero_troll_warlord",
 		LocalizedName: "Troll Warlord",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Pusher", "Disabler", "Durable"},
 	},
 	96: DotaHero{
 		Name:          "npc_dota_hero_centaur",
 		LocalizedName: "Centaur Warrunner",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Durable", "Initiator", "Disabler", "Nuker", "Carry"},
 	},
 	97: DotaHero{
 		Name:          "npc_dota_hero_magnataur",
 		LocalizedName: "Magnus",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Initiator", "Disabler", "Nuker", "Escape"},
 	},
 	98: DotaHero{
 		Name:          "npc_dota_hero_shredder",
 		LocalizedName: "Timbersaw",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Nuker", "Durable", "Escape"},
 	},
 	99: DotaHero{
 		Name:          "npc_dota_hero_bristleback",
 		LocalizedName: "Bristleback",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Carry", "Durable", "Initiator", "Nuker"},
 	},
 	100: DotaHero{
 		Name:          "npc_dota_hero_tusk",
 		LocalizedName: "Tusk",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Initiator", "Disabler", "Nuker"},
 	},
 	101: DotaHero{
 		Name:          "npc_dota_hero_skywrath_mage",
 		LocalizedName: "Skywrath Mage",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Support", "Nuker", "Disabler"},
 	},
 	102: DotaHero{
 		Name:          "npc_dota_hero_abaddon",
 		LocalizedName: "Abaddon",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Support", "Carry", "Durable"},
 	},
 	103: DotaHero{
 		Name:          "npc_dota_hero_elder_titan",
 		LocalizedName: "Elder Titan",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Initiator", "Disabler", "Nuker", "Durable"},
 	},
 	104: DotaHero{
 		Name:          "npc_dota_hero_legion_commander",
 		LocalizedName: "Legion Commander",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Carry", "Disabler", "Initiator", "Durable", "Nuker"},
 	},
 	105: DotaHero{
 		Name:          "npc_dota_hero_techies",
 		LocalizedName: "Techies",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Nuker", "Disabler"},
 	},
 	106: DotaHero{
 		Name:          "npc_dota_hero_ember_spirit",
 		LocalizedName: "Ember Spirit",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Escape", "Nuker", "Disabler", "Initiator"},
 	},
 	107: DotaHero{
 		Name:          "npc_dota_hero_earth_spirit",
 		LocalizedName: "Earth Spirit",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Nuker", "Escape", "Disabler", "Initiator", "Durable"},
 	},
 	108: DotaHero{
 		Name:          "npc_dota_hero_abyssal_underlord",
 		LocalizedName: "Underlord",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Support", "Nuker", "Disabler", "Durable", "Escape"},
 	},
 	109: DotaHero{
 		Name:          "npc_dota_hero_terrorblade",
 		LocalizedName: "Terrorblade",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Pusher", "Nuker"},
 	},
 	110: DotaHero{
 		Name:          "npc_dota_hero_phoenix",
 		LocalizedName: "Phoenix",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Support", "Nuker", "Initiator", "Escape", "Disabler"},
 	},
 	111: DotaHero{
 		Name:          "npc_dota_hero_oracle",
 		LocalizedName: "Oracle",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Support", "Nuker", "Disabler", "Escape"},
 	},
 	112: DotaHero{
 		Name:          "npc_dota_hero_winter_wyvern",
 		LocalizedName: "Winter Wyvern",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Support", "Disabler", "Nuker"},
 	},
 	113: DotaHero{
 		Name:          "npc_dota_hero_arc_warden",
 		LocalizedName: "Arc Warden",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Escape", "Nuker"},
 	},
 	114: DotaHero{
 		Name:          "npc_dota_hero_monkey_king",
 		LocalizedName: "Monkey King",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Escape", "Disabler", "Initiator"},
 	},
 	119: DotaHero{
 		Name:          "npc_dota_hero_dark_willow",
 		LocalizedName: "Dark Willow",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Support", "Nuker", "Disabler", "Escape"},
 	},
 	120: DotaHero{
 		Name:          "npc_dota_hero_pangolier",
 		LocalizedName: "Pangolier",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Carry", "Nuker", "Disabler", "Durable", "Escape", "Initiator"},
 	},
 	121: DotaHero{
 		Name:          "npc_dota_hero_grimstroke",
 		LocalizedName: "Grimstroke",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Support", "Nuker", "Disabler", "Escape"},
 	},
 	123: DotaHero{
 		Name:          "npc_dota_hero_hoodwink",
 		LocalizedName: "Hoodwink",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Support", "Nuker", "Escape", "Disabler"},
 	},
 	126: DotaHero{
 		Name:          "npc_dota_hero_void_spirit",
 		LocalizedName: "Void Spirit",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Carry", "Escape", "Nuker", "Disabler"},
 	},
 	128: DotaHero{
 		Name:          "npc_dota_hero_snapfire",
 		LocalizedName: "Snapfire",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Support", "Nuker", "Disabler", "Escape"},
 	},
 	129: DotaHero{
 		Name:          "npc_dota_hero_mars",
 		LocalizedName: "Mars",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Carry", "Initiator", "Disabler", "Durable"},
 	},
 	131: DotaHero{
 		Name:          "npc_dota_hero_ringmaster",
 		LocalizedName: "Ringmaster",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Support", "Escape", "Nuker", "Disabler"},
 	},
 	135: DotaHero{
 		Name:          "npc_dota_hero_dawnbreaker",
 		LocalizedName: "Dawnbreaker",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Carry", "Durable"},
 	},
 	136: DotaHero{
 		Name:          "npc_dota_hero_marci",
 		LocalizedName: "Marci",
+		PrimaryAttr:   "all",
+		Roles:         []string{"Support", "Carry", "Initiator", "Disabler", "Escape"},
 	},
 	137: DotaHero{
 		Name:          "npc_dota_hero_primal_beast",
 		LocalizedName: "Primal Beast",
+		PrimaryAttr:   "str",
+		Roles:         []string{"Initiator", "Durable", "Disabler"},
 	},
 	138: DotaHero{
 		Name:          "npc_dota_hero_muerta",
 		LocalizedName: "Muerta",
+		PrimaryAttr:   "int",
+		Roles:         []string{"Carry", "Nuker", "Disabler"},
 	},
 	145: DotaHero{
 		Name:          "npc_dota_hero_kez",
 		LocalizedName: "Kez",
+		PrimaryAttr:   "agi",
+		Roles:         []string{"Carry", "Escape", "Disabler"},
 	},
 }
@@ -0,0 +1,152 @@
+package capabilities
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/victormamede/benebott/internal/chart"
+	"google.golang.org/genai"
+)
+
+const dotaMaxChartMatches = 50
+
+var DotaPlayerChartDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
+	Name:        "dota_player_chart",
+	Description: "Renders a chart of a dota player's recent matches and sends it to the chat as an image. Prefer it over long text tables of match stats.",
+	Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: dotaFilterProperties(map[string]*genai.Schema{
+			"playerId": &genai.Schema{Type: genai.TypeString, Description: "The player account ID, SteamID or profile URL"},
+			"chart": &genai.Schema{
+				Type:        genai.TypeString,
+				Enum:        []string{"performance", "heroes", "win_rate"},
+				Description: "performance plots kills, deaths and assists per match, heroes the number of matches per hero and win_rate the win rate over time",
+			},
+			"limit": &genai.Schema{Type: genai.TypeInteger, Description: fmt.Sprintf("The number of matches to plot, not higher than %d", dotaMaxChartMatches)},
+		}),
+		Required: []string{"playerId", "chart"},
+	},
+}
+
+func DotaPlayerChart(ctx context.Context, b *bot.Bot, update *models.Update, playerId string, kind string, filters DotaMatchFilters, limit int) CallResponse {
+	log.Println("Rendering dota", kind, "chart for player", playerId)
+
+	callResponse := map[string]any{}
+	playerId, err := ResolveDotaAccount(playerId)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	filters.Limit = min(max(limit, 1), dotaMaxChartMatches)
+	items, err := fetchDotaPlayerMatches(ctx, playerId, filters)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	// OpenDota lists the most recent match first
+	slices.Reverse(items)
+
+	var image []byte
+	switch kind {
+	case "performance":
+		image, err = dotaPerformanceChart(items)
+	case "heroes":
+		image, err = dotaHeroesChart(items)
+	case "win_rate":
+		image, err = dotaWinRateChart(items)
+	default:
+		err = fmt.Errorf("unknown chart %q", kind)
+	}
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	_, err = b.SendPhoto(ctx, &bot.SendPhotoParams{
+		ChatID:          update.Message.Chat.ID,
+		Photo:           &models.InputFileUpload{Filename: kind + ".png", Data: bytes.NewReader(image)},
+		ReplyParameters: &models.ReplyParameters{MessageID: update.Message.ID},
+	})
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	callResponse["sent"] = true
+	callResponse["matches"] = len(items)
+	return callResponse
+}
+
+func dotaMatchLabels(items []DotaPlayerMatchResponse) []string {
+	labels := []string{}
+	for _, item := range items {
+		labels = append(labels, time.Unix(int64(item.StartTime), 0).Format("02/01"))
+	}
+
+	return labels
+}
+
+func dotaPerformanceChart(items []DotaPlayerMatchResponse) ([]byte, error) {
+	kills := chart.Series{Name: "Kills"}
+	deaths := chart.Series{Name: "Deaths"}
+	assists := chart.Series{Name: "Assists"}
+	hi := 0.0
+
+	for _, item := range items {
+		kills.Values = append(kills.Values, item.Kills)
+		deaths.Values = append(deaths.Values, item.Deaths)
+		assists.Values = append(assists.Values, item.Assists)
+		hi = max(hi, item.Kills, item.Deaths, item.Assists)
+	}
+
+	title := fmt.Sprintf("K/D/A over the last %d matches", len(items))
+	return chart.Line(title, dotaMatchLabels(items), []chart.Series{kills, deaths, assists}, 0, hi)
+}
+
+func dotaHeroesChart(items []DotaPlayerMatchResponse) ([]byte, error) {
+	games := map[string]float64{}
+	for _, item := range items {
+		games[dotaHeroName(item.HeroId)]++
+	}
+
+	names := []string{}
+	for name := range games {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Or(cmp.Compare(games[b], games[a]), cmp.Compare(a, b))
+	})
+	names = names[:min(len(names), 10)]
+
+	values := []float64{}
+	for _, name := range names {
+		values = append(values, games[name])
+	}
+
+	title := fmt.Sprintf("Heroes played in the last %d matches", len(items))
+	return chart.HorizontalBar(title, names, values)
+}
+
+func dotaWinRateChart(items []DotaPlayerMatchResponse) ([]byte, error) {
+	winRates := chart.Series{Name: "Win rate %"}
+	wins := 0.0
+
+	for i, item := range items {
+		if item.won() {
+			wins++
+		}
+		winRates.Values = append(winRates.Values, winRate(wins, float64(i+1)))
+	}
+
+	title := fmt.Sprintf("Win rate over the last %d matches", len(items))
+	return chart.Line(title, dotaMatchLabels(items), []chart.Series{winRates}, 0, 100)
+}
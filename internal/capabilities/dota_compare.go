@@ -0,0 +1,189 @@
+package capabilities
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+
+	"google.golang.org/genai"
+)
+
+const (
+	dotaCompareMatches = 20
+	// Requests sent for each compared player
+	dotaCompareRequests = 5
+)
+
+var DotaComparePlayersDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
+	Name:        "dota_compare_players",
+	Description: "Compares two or more dota players side by side: win rate, KDA, most played heroes and their results when playing together or against each other.",
+	Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: dotaFilterProperties(map[string]*genai.Schema{
+			"players": &genai.Schema{
+				Type:        genai.TypeArray,
+				Items:       &genai.Schema{Type: genai.TypeString},
+				Description: "The dota account IDs or the telegram user IDs of linked members to compare",
+			},
+		}),
+		Required: []string{"players"},
+	},
+}
+
+type dotaComparedPlayer struct {
+	profile DotaPlayer
+	wl      dotaWinLossResponse
+	matches []DotaPlayerMatchResponse
+	heroes  []dotaPlayerHeroResponse
+	peers   []dotaPlayerPeerResponse
+}
+
+func DotaComparePlayers(ctx context.Context, players []string, filters DotaMatchFilters) CallResponse {
+	log.Println("Comparing dota players", players)
+
+	callResponse := map[string]any{}
+	if len(players) < 2 {
+		callResponse["error"] = "at least two players are needed for a comparison"
+		return callResponse
+	}
+
+	accounts := make([]string, len(players))
+	for i, player := range players {
+		account, err := ResolveDotaAccount(player)
+		if err != nil {
+			callResponse["error"] = err.Error()
+			return callResponse
+		}
+		accounts[i] = account
+	}
+
+	compared := make([]dotaComparedPlayer, len(accounts))
+	err := openDotaEach(len(accounts), dotaCompareRequests, func(i int) error {
+		var err error
+		compared[i], err = fetchDotaComparedPlayer(ctx, accounts[i], filters)
+		if err != nil {
+			return fmt.Errorf("player %s: %w", accounts[i], err)
+		}
+		return nil
+	})
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	parsedPlayers := []any{}
+	for i, player := range compared {
+		parsedPlayers = append(parsedPlayers, player.summary(accounts[i]))
+	}
+
+	parsedPairs := []any{}
+	for i := range accounts {
+		for j := i + 1; j < len(accounts); j++ {
+			parsedPairs = append(parsedPairs, compared[i].pairSummary(accounts[i], accounts[j]))
+		}
+	}
+
+	callResponse["players"] = parsedPlayers
+	callResponse["head_to_head"] = parsedPairs
+	return callResponse
+}
+
+func fetchDotaComparedPlayer(ctx context.Context, account string, filters DotaMatchFilters) (dotaComparedPlayer, error) {
+	player := dotaComparedPlayer{}
+
+	var err error
+	player.profile, err = dotaFallback(func(provider DotaProvider) (DotaPlayer, error) {
+		return provider.Player(ctx, account)
+	})
+	if err != nil {
+		return player, err
+	}
+
+	err = openDotaGet(ctx, fmt.Sprintf("players/%s/wl", account), filters.query(), &player.wl)
+	if err != nil {
+		return player, err
+	}
+
+	matchFilters := filters
+	matchFilters.Limit = dotaCompareMatches
+	player.matches, err = fetchDotaPlayerMatches(ctx, account, matchFilters)
+	if err != nil {
+		return player, err
+	}
+
+	err = openDotaGet(ctx, fmt.Sprintf("players/%s/heroes", account), filters.query(), &player.heroes)
+	if err != nil {
+		return player, err
+	}
+
+	err = openDotaGet(ctx, fmt.Sprintf("players/%s/peers", account), filters.query(), &player.peers)
+	return player, err
+}
+
+func (p dotaComparedPlayer) summary(account string) map[string]any {
+	var kills, deaths, assists float64
+	for _, match := range p.matches {
+		kills += match.Kills
+		deaths += match.Deaths
+		assists += match.Assists
+	}
+
+	kda := map[string]any{}
+	if len(p.matches) > 0 {
+		games := float64(len(p.matches))
+		kda = map[string]any{
+			"matches":     len(p.matches),
+			"avg_kills":   math.Round(kills/games*10) / 10,
+			"avg_deaths":  math.Round(deaths/games*10) / 10,
+			"avg_assists": math.Round(assists/games*10) / 10,
+			"kda_ratio":   math.Round((kills+assists)/max(deaths, 1)*100) / 100,
+		}
+	}
+
+	topHeroes := []any{}
+	for _, hero := range p.heroes[:min(len(p.heroes), 3)] {
+		if hero.Games == 0 {
+			break
+		}
+		topHeroes = append(topHeroes, map[string]any{
+			"hero":     dotaHeroName(hero.HeroId),
+			"games":    hero.Games,
+			"win_rate": winRate(hero.Win, hero.Games),
+		})
+	}
+
+	return map[string]any{
+		"account_id": account,
+		"name":       p.profile.Name,
+		"rank":       DotaRank(p.profile.RankTier, p.profile.LeaderboardRank),
+		"wins":       p.wl.Win,
+		"losses":     p.wl.Lose,
+		"win_rate":   winRate(p.wl.Win, p.wl.Win+p.wl.Lose),
+		"recent_kda": kda,
+		"top_heroes": topHeroes,
+	}
+}
+
+func (p dotaComparedPlayer) pairSummary(account string, other string) map[string]any {
+	pair := map[string]any{
+		"players":        []string{account, other},
+		"games_together": 0,
+		"games_against":  0,
+	}
+
+	for _, peer := range p.peers {
+		if strconv.FormatFloat(peer.AccountId, 'f', 0, 64) != other {
+			continue
+		}
+
+		pair["games_together"] = peer.WithGames
+		pair["wins_together"] = peer.WithWin
+		pair["win_rate_together"] = winRate(peer.WithWin, peer.WithGames)
+		pair["games_against"] = peer.AgainstGames
+		pair[fmt.Sprintf("wins_against_by_%s", account)] = peer.AgainstWin
+	}
+
+	return pair
+}
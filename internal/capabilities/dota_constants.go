@@ -0,0 +1,121 @@
+package capabilities
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var dotaMedals map[int]string = map[int]string{
+	1: "Herald",
+	2: "Guardian",
+	3: "Crusader",
+	4: "Archon",
+	5: "Legend",
+	6: "Ancient",
+	7: "Divine",
+	8: "Immortal",
+}
+
+var dotaGameModes map[int]string = map[int]string{
+	0:  "Unknown",
+	1:  "All Pick",
+	2:  "Captains Mode",
+	3:  "Random Draft",
+	4:  "Single Draft",
+	5:  "All Random",
+	6:  "Intro",
+	7:  "Diretide",
+	8:  "Reverse Captains Mode",
+	9:  "Greeviling",
+	10: "Tutorial",
+	11: "Mid Only",
+	12: "Least Played",
+	13: "Limited Heroes",
+	14: "Compendium Matchmaking",
+	15: "Custom",
+	16: "Captains Draft",
+	17: "Balanced Draft",
+	18: "Ability Draft",
+	19: "Event",
+	20: "All Random Deathmatch",
+	21: "1v1 Mid",
+	22: "All Draft",
+	23: "Turbo",
+	24: "Mutation",
+	25: "Coaches Challenge",
+}
+
+var dotaLobbyTypes map[int]string = map[int]string{
+	0:  "Normal",
+	1:  "Practice",
+	2:  "Tournament",
+	3:  "Tutorial",
+	4:  "Co-op Bots",
+	5:  "Ranked Team",
+	6:  "Ranked Solo",
+	7:  "Ranked",
+	8:  "1v1 Mid",
+	9:  "Battle Cup",
+	10: "Local Bots",
+	11: "Spectator",
+	12: "Event",
+	13: "Gauntlet",
+	14: "New Player",
+	15: "Featured",
+}
+
+// DotaRank decodes an OpenDota rank tier (e.g. 54 is Legend with 4 stars).
+// Immortal players have no stars, leaderboardRank is used instead when known.
+func DotaRank(rankTier int, leaderboardRank int) map[string]any {
+	if rankTier <= 0 {
+		return map[string]any{"medal": "Uncalibrated"}
+	}
+
+	medal, ok := dotaMedals[rankTier/10]
+	if !ok {
+		return map[string]any{"medal": fmt.Sprintf("Unknown (%d)", rankTier)}
+	}
+
+	rank := map[string]any{"medal": medal}
+	if rankTier/10 == 8 {
+		if leaderboardRank > 0 {
+			rank["leaderboard_rank"] = leaderboardRank
+		}
+		return rank
+	}
+
+	rank["stars"] = rankTier % 10
+	return rank
+}
+
+func DotaGameMode(id int) string {
+	if name, ok := dotaGameModes[id]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Unknown (%d)", id)
+}
+
+func DotaLobbyType(id int) string {
+	if name, ok := dotaLobbyTypes[id]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Unknown (%d)", id)
+}
+
+func findDotaConstant(constants map[int]string, name string) (int, bool) {
+	if id, err := strconv.Atoi(name); err == nil {
+		_, ok := constants[id]
+		return id, ok
+	}
+
+	for id, constant := range constants {
+		if strings.EqualFold(constant, name) {
+			return id, true
+		}
+	}
+
+	return 0, false
+}
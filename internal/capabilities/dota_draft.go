@@ -0,0 +1,293 @@
+package capabilities
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+const (
+	dotaMaxRandomHeroes   = 5
+	dotaMaxSuggestions    = 10
+	dotaMatchupMinGames   = 20
+	dotaCountersPerAlly   = 3
+	dotaDefaultRecentSkip = 20
+)
+
+var dotaRoles []string = []string{"Carry", "Support", "Nuker", "Disabler", "Initiator", "Durable", "Escape", "Pusher"}
+
+var dotaAttributes []string = []string{"str", "agi", "int", "all"}
+
+var DotaRandomHeroDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
+	Name:        "dota_random_hero",
+	Description: "Picks random dota heroes, optionally restricted by role or primary attribute and skipping heroes a player played recently.",
+	Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"count":     &genai.Schema{Type: genai.TypeInteger, Description: fmt.Sprintf("How many heroes to pick, not higher than %d", dotaMaxRandomHeroes)},
+			"role":      &genai.Schema{Type: genai.TypeString, Enum: dotaRoles},
+			"attribute": &genai.Schema{Type: genai.TypeString, Enum: dotaAttributes, Description: "The primary attribute, all means universal"},
+			"playerId":  &genai.Schema{Type: genai.TypeString, Description: "Skip heroes recently played by this player account ID, SteamID or profile URL"},
+			"recent":    &genai.Schema{Type: genai.TypeInteger, Description: fmt.Sprintf("How many recent matches of the player to look at, defaults to %d", dotaDefaultRecentSkip)},
+			"exclude": &genai.Schema{
+				Type:        genai.TypeArray,
+				Items:       &genai.Schema{Type: genai.TypeString},
+				Description: "Hero names that must not be picked",
+			},
+		},
+	},
+}
+
+func DotaRandomHero(ctx context.Context, count int, role string, attribute string, playerId string, recent int, exclude []string) CallResponse {
+	log.Println("Picking", count, "random dota heroes")
+
+	callResponse := map[string]any{}
+
+	excluded := map[int]bool{}
+	for _, name := range exclude {
+		if id, ok := FindDotaHero(name); ok {
+			excluded[id] = true
+		}
+	}
+
+	if playerId != "" {
+		playerId, err := ResolveDotaAccount(playerId)
+		if err != nil {
+			callResponse["error"] = err.Error()
+			return callResponse
+		}
+
+		filters := DotaMatchFilters{Limit: min(max(recent, 1), dotaMaxChartMatches), GameMode: -1, LobbyType: -1}
+		items, err := fetchDotaPlayerMatches(ctx, playerId, filters)
+		if err != nil {
+			callResponse["error"] = err.Error()
+			return callResponse
+		}
+		for _, item := range items {
+			excluded[int(item.HeroId)] = true
+		}
+	}
+
+	pool := []int{}
+	for id, hero := range heroes {
+		if excluded[id] {
+			continue
+		}
+		if role != "" && !slices.Contains(hero.Roles, role) {
+			continue
+		}
+		if attribute != "" && hero.PrimaryAttr != attribute {
+			continue
+		}
+		pool = append(pool, id)
+	}
+
+	rand.Shuffle(len(pool), func(i, j int) {
+		pool[i], pool[j] = pool[j], pool[i]
+	})
+
+	picked := []any{}
+	for _, id := range pool[:min(len(pool), min(max(count, 1), dotaMaxRandomHeroes))] {
+		picked = append(picked, map[string]any{
+			"hero":      heroes[id].LocalizedName,
+			"attribute": heroes[id].PrimaryAttr,
+			"roles":     heroes[id].Roles,
+		})
+	}
+
+	callResponse["heroes"] = picked
+	callResponse["pool_size"] = len(pool)
+	return callResponse
+}
+
+var DotaDraftHelperDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
+	Name:        "dota_draft_helper",
+	Description: "Suggests dota heroes for a draft based on hero matchup data: counters to the enemy picks and heroes that cover the weaknesses of the allied picks.",
+	Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"allies": &genai.Schema{
+				Type:        genai.TypeArray,
+				Items:       &genai.Schema{Type: genai.TypeString},
+				Description: "The heroes already picked by our team",
+			},
+			"enemies": &genai.Schema{
+				Type:        genai.TypeArray,
+				Items:       &genai.Schema{Type: genai.TypeString},
+				Description: "The heroes already picked by the enemy team",
+			},
+			"role":  &genai.Schema{Type: genai.TypeString, Enum: dotaRoles, Description: "Only suggest heroes with this role"},
+			"count": &genai.Schema{Type: genai.TypeInteger, Description: fmt.Sprintf("How many heroes to suggest, not higher than %d", dotaMaxSuggestions)},
+		},
+	},
+}
+
+type dotaMatchupResponse struct {
+	HeroId      float64 `json:"hero_id"`
+	GamesPlayed float64 `json:"games_played"`
+	Wins        float64 `json:"wins"`
+}
+
+// dotaMatchups maps, for a hero, every opponent to the win rate of that
+// opponent against the hero.
+type dotaMatchups map[int]float64
+
+func fetchDotaMatchups(ctx context.Context, ids []int) ([]dotaMatchups, error) {
+	matchups := make([]dotaMatchups, len(ids))
+	err := openDotaEach(len(ids), 1, func(i int) error {
+		items := []dotaMatchupResponse{}
+		err := openDotaGet(ctx, fmt.Sprintf("heroes/%d/matchups", ids[i]), nil, &items)
+		if err != nil {
+			return err
+		}
+
+		matchups[i] = dotaMatchups{}
+		for _, item := range items {
+			if item.GamesPlayed >= dotaMatchupMinGames {
+				matchups[i][int(item.HeroId)] = 1 - item.Wins/item.GamesPlayed
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matchups, nil
+}
+
+func findDotaHeroes(names []string) ([]int, error) {
+	ids := []int{}
+	for _, name := range names {
+		id, ok := FindDotaHero(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("unknown hero %q", name)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func DotaDraftHelper(ctx context.Context, allies []string, enemies []string, role string, count int) CallResponse {
+	log.Println("Helping with dota draft", allies, "vs", enemies)
+
+	callResponse := map[string]any{}
+
+	allyIds, err := findDotaHeroes(allies)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+	enemyIds, err := findDotaHeroes(enemies)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+	if len(allyIds)+len(enemyIds) == 0 {
+		callResponse["error"] = "at least one picked hero is needed"
+		return callResponse
+	}
+
+	matchups, err := fetchDotaMatchups(ctx, append(slices.Clone(allyIds), enemyIds...))
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+	allyMatchups, enemyMatchups := matchups[:len(allyIds)], matchups[len(allyIds):]
+
+	// The heroes that do best against each ally are the threats to cover
+	threats := []int{}
+	for _, m := range allyMatchups {
+		ids := []int{}
+		for id := range m {
+			ids = append(ids, id)
+		}
+		slices.SortFunc(ids, func(a, b int) int {
+			return cmp.Compare(m[b], m[a])
+		})
+		threats = append(threats, ids[:min(len(ids), dotaCountersPerAlly)]...)
+	}
+	slices.Sort(threats)
+	threats = slices.Compact(threats)
+
+	threatMatchups, err := fetchDotaMatchups(ctx, threats)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	picked := slices.Concat(allyIds, enemyIds)
+	counters := dotaRankCandidates(enemyMatchups, picked, role)
+	synergies := dotaRankCandidates(threatMatchups, picked, role)
+
+	count = min(max(count, 1), dotaMaxSuggestions)
+	if len(enemyIds) > 0 {
+		callResponse["counters"] = counters[:min(len(counters), count)]
+	}
+	if len(allyIds) > 0 {
+		callResponse["synergies"] = synergies[:min(len(synergies), count)]
+	}
+
+	warnings := []any{}
+	for i, ally := range allyIds {
+		for _, enemy := range enemyIds {
+			if winRate, ok := allyMatchups[i][enemy]; ok && winRate > 0.52 {
+				warnings = append(warnings, fmt.Sprintf("%s wins %.1f%% of games against %s",
+					heroes[enemy].LocalizedName, winRate*100, heroes[ally].LocalizedName))
+			}
+		}
+	}
+	callResponse["warnings"] = warnings
+
+	return callResponse
+}
+
+// dotaRankCandidates ranks every hero not yet picked by its average win rate
+// against the heroes whose matchups are given.
+func dotaRankCandidates(against []dotaMatchups, picked []int, role string) []any {
+	type candidate struct {
+		id      int
+		winRate float64
+	}
+
+	candidates := []candidate{}
+	for id, hero := range heroes {
+		if slices.Contains(picked, id) || (role != "" && !slices.Contains(hero.Roles, role)) {
+			continue
+		}
+
+		total, known := 0.0, 0
+		for _, m := range against {
+			if winRate, ok := m[id]; ok {
+				total += winRate
+				known++
+			}
+		}
+		if known > 0 {
+			candidates = append(candidates, candidate{id, total / float64(known)})
+		}
+	}
+
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		return cmp.Compare(b.winRate, a.winRate)
+	})
+
+	ranked := []any{}
+	for _, c := range candidates {
+		ranked = append(ranked, map[string]any{
+			"hero":     heroes[c.id].LocalizedName,
+			"roles":    heroes[c.id].Roles,
+			"win_rate": math.Round(c.winRate*1000) / 10,
+		})
+	}
+
+	return ranked
+}
@@ -0,0 +1,232 @@
+package capabilities
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"google.golang.org/genai"
+)
+
+const dotaFeedingStreak = 4
+
+var DotaMatchHighlightsDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
+	Name:        "dota_match_highlights",
+	Description: "Finds the notable events of a dota match: rampages, ultra kills, first blood, top damage, comebacks and feeding streaks. Use it to hype or roast the players.",
+	Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"matchId": &genai.Schema{Type: genai.TypeString, Description: "The match ID or a match URL"},
+		},
+		Required: []string{"matchId"},
+	},
+}
+
+type dotaKillLogEntry struct {
+	Time float64 `json:"time"`
+	Key  string  `json:"key"`
+}
+
+type dotaMatchPlayer struct {
+	AccountId         float64            `json:"account_id"`
+	Personaname       string             `json:"personaname"`
+	HeroId            float64            `json:"hero_id"`
+	IsRadiant         bool               `json:"isRadiant"`
+	Kills             float64            `json:"kills"`
+	Deaths            float64            `json:"deaths"`
+	Assists           float64            `json:"assists"`
+	HeroDamage        float64            `json:"hero_damage"`
+	TowerDamage       float64            `json:"tower_damage"`
+	HeroHealing       float64            `json:"hero_healing"`
+	NetWorth          float64            `json:"net_worth"`
+	MultiKills        map[string]float64 `json:"multi_kills"`
+	FirstbloodClaimed float64            `json:"firstblood_claimed"`
+	KillsLog          []dotaKillLogEntry `json:"kills_log"`
+}
+
+type dotaMatchResponse struct {
+	MatchId        float64           `json:"match_id"`
+	RadiantWin     bool              `json:"radiant_win"`
+	Duration       float64           `json:"duration"`
+	RadiantScore   float64           `json:"radiant_score"`
+	DireScore      float64           `json:"dire_score"`
+	GameMode       float64           `json:"game_mode"`
+	Version        any               `json:"version"`
+	RadiantGoldAdv []float64         `json:"radiant_gold_adv"`
+	Players        []dotaMatchPlayer `json:"players"`
+}
+
+func (p dotaMatchPlayer) name() string {
+	name := p.Personaname
+	if name == "" {
+		name = "Anonymous"
+	}
+
+	return fmt.Sprintf("%s (%s)", name, dotaHeroName(p.HeroId))
+}
+
+func (p dotaMatchPlayer) team() string {
+	if p.IsRadiant {
+		return "radiant"
+	}
+
+	return "dire"
+}
+
+func (m dotaMatchResponse) winner() string {
+	if m.RadiantWin {
+		return "radiant"
+	}
+
+	return "dire"
+}
+
+func DotaMatchHighlights(ctx context.Context, matchId string) CallResponse {
+	log.Println("Getting dota highlights for match", matchId)
+
+	callResponse := map[string]any{}
+
+	matchId, err := ParseDotaMatchID(matchId)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	match, err := dotaFallback(func(provider DotaProvider) (dotaMatchResponse, error) {
+		return provider.Match(ctx, matchId)
+	})
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	callResponse["match_id"] = matchId
+	callResponse["winner"] = match.winner()
+	callResponse["score"] = fmt.Sprintf("radiant %.0f x %.0f dire", match.RadiantScore, match.DireScore)
+	callResponse["duration_minutes"] = match.Duration / 60.0
+	callResponse["game_mode"] = DotaGameMode(int(match.GameMode))
+	callResponse["parsed"] = match.Version != nil
+	callResponse["highlights"] = dotaHighlights(match)
+	if match.Version == nil {
+		callResponse["note"] = "the match is not parsed, only basic highlights are available. dota_request_parse can make the rest available"
+	}
+
+	return callResponse
+}
+
+func dotaHighlights(match dotaMatchResponse) []any {
+	highlights := []any{}
+	add := func(kind string, player dotaMatchPlayer, value any, description string) {
+		highlights = append(highlights, map[string]any{
+			"type":        kind,
+			"player":      player.name(),
+			"team":        player.team(),
+			"value":       value,
+			"description": description,
+		})
+	}
+
+	if len(match.Players) == 0 {
+		return highlights
+	}
+
+	for _, player := range match.Players {
+		if rampages := player.MultiKills["5"]; rampages > 0 {
+			add("rampage", player, rampages, fmt.Sprintf("%.0f rampage(s)", rampages))
+		}
+		if ultraKills := player.MultiKills["4"]; ultraKills > 0 {
+			add("ultra_kill", player, ultraKills, fmt.Sprintf("%.0f ultra kill(s)", ultraKills))
+		}
+		if player.FirstbloodClaimed > 0 {
+			add("first_blood", player, nil, "drew first blood")
+		}
+	}
+
+	top := func(value func(dotaMatchPlayer) float64) dotaMatchPlayer {
+		return slices.MaxFunc(match.Players, func(a, b dotaMatchPlayer) int {
+			return cmp.Compare(value(a), value(b))
+		})
+	}
+
+	damage := top(func(p dotaMatchPlayer) float64 { return p.HeroDamage })
+	add("top_hero_damage", damage, damage.HeroDamage, fmt.Sprintf("dealt the most hero damage (%.0f)", damage.HeroDamage))
+
+	kills := top(func(p dotaMatchPlayer) float64 { return p.Kills })
+	add("most_kills", kills, kills.Kills, fmt.Sprintf("%.0f/%.0f/%.0f", kills.Kills, kills.Deaths, kills.Assists))
+
+	deaths := top(func(p dotaMatchPlayer) float64 { return p.Deaths })
+	add("most_deaths", deaths, deaths.Deaths, fmt.Sprintf("%.0f/%.0f/%.0f", deaths.Kills, deaths.Deaths, deaths.Assists))
+
+	if comeback := dotaComeback(match); comeback > 0 {
+		highlights = append(highlights, map[string]any{
+			"type":        "comeback",
+			"team":        match.winner(),
+			"value":       comeback,
+			"description": fmt.Sprintf("won after being %.0f gold behind", comeback),
+		})
+	}
+
+	for _, player := range match.Players {
+		if streak := dotaFeedingStreakOf(match, player); streak >= dotaFeedingStreak {
+			add("feeding_streak", player, streak, fmt.Sprintf("died %d times in a row without a kill", streak))
+		}
+	}
+
+	return highlights
+}
+
+// dotaComeback returns the biggest gold deficit overcome by the winning team.
+func dotaComeback(match dotaMatchResponse) float64 {
+	deficit := 0.0
+	for _, advantage := range match.RadiantGoldAdv {
+		if !match.RadiantWin {
+			advantage = -advantage
+		}
+		deficit = max(deficit, -advantage)
+	}
+
+	return deficit
+}
+
+// dotaFeedingStreakOf finds the longest run of deaths without a kill, using
+// the kill logs of parsed matches.
+func dotaFeedingStreakOf(match dotaMatchResponse, player dotaMatchPlayer) int {
+	hero, ok := heroes[int(player.HeroId)]
+	if !ok {
+		return 0
+	}
+
+	type event struct {
+		time float64
+		kill bool
+	}
+
+	events := []event{}
+	for _, entry := range player.KillsLog {
+		events = append(events, event{entry.Time, true})
+	}
+	for _, other := range match.Players {
+		for _, entry := range other.KillsLog {
+			if entry.Key == hero.Name {
+				events = append(events, event{entry.Time, false})
+			}
+		}
+	}
+	slices.SortFunc(events, func(a, b event) int {
+		return cmp.Compare(a.time, b.time)
+	})
+
+	longest, current := 0, 0
+	for _, e := range events {
+		if e.kill {
+			current = 0
+			continue
+		}
+		current++
+		longest = max(longest, current)
+	}
+
+	return longest
+}
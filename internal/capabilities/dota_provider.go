@@ -0,0 +1,107 @@
+package capabilities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+
+	"github.com/spf13/viper"
+)
+
+// DotaProvider is a source of dota game stats. Capabilities go through
+// dotaFallback so that another provider answers when one fails.
+//
+// The fallback only covers the four calls below: player profiles, match
+// history (and so charts), match details and the hero list. Every other dota
+// tool (win/loss, player heroes, peers, records, comparisons, draft matchups,
+// player search and match parsing) calls OpenDota directly and fails when
+// OpenDota does.
+type DotaProvider interface {
+	Name() string
+	Player(ctx context.Context, accountId string) (DotaPlayer, error)
+	Matches(ctx context.Context, accountId string, filters DotaMatchFilters) ([]DotaPlayerMatchResponse, error)
+	Match(ctx context.Context, matchId string) (dotaMatchResponse, error)
+	Heroes(ctx context.Context) ([]DotaHeroInfo, error)
+}
+
+type DotaPlayer struct {
+	AccountId       string
+	Name            string
+	Avatar          string
+	ProfileURL      string
+	RankTier        int
+	LeaderboardRank int
+	// Account is the whole account payload, in the shape of the OpenDota
+	// players endpoint. Providers with less data fill in what they have.
+	Account map[string]any
+}
+
+type DotaHeroInfo struct {
+	Id            int
+	Name          string
+	LocalizedName string
+	PrimaryAttr   string
+	Roles         []string
+}
+
+var dotaProviders map[string]DotaProvider = map[string]DotaProvider{
+	"opendota": openDotaProvider{},
+	"stratz":   stratzProvider{},
+}
+
+// activeDotaProviders reads the providers to use, in order, from the
+// dota.providers config.
+func activeDotaProviders() []DotaProvider {
+	names := viper.GetStringSlice("dota.providers")
+	if len(names) == 0 {
+		names = []string{"opendota"}
+	}
+
+	providers := []DotaProvider{}
+	for _, name := range names {
+		provider, ok := dotaProviders[name]
+		if !ok {
+			log.Println("Unknown dota provider", name)
+			continue
+		}
+		providers = append(providers, provider)
+	}
+
+	return providers
+}
+
+func dotaFallback[T any](call func(DotaProvider) (T, error)) (T, error) {
+	var result T
+	errs := []error{}
+
+	for _, provider := range activeDotaProviders() {
+		result, err := call(provider)
+		if err == nil {
+			return result, nil
+		}
+
+		log.Println("Dota provider", provider.Name(), "failed:", err)
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+	}
+
+	if len(errs) == 0 {
+		return result, errors.New("no dota provider configured")
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// payload returns the account payload with the rank tier decoded.
+func (p DotaPlayer) payload() map[string]any {
+	payload := maps.Clone(p.Account)
+	if payload == nil {
+		payload = map[string]any{}
+	}
+
+	delete(payload, "rank_tier")
+	delete(payload, "leaderboard_rank")
+	payload["rank"] = DotaRank(p.RankTier, p.LeaderboardRank)
+	return payload
+}
@@ -0,0 +1,99 @@
+package capabilities
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"google.golang.org/genai"
+)
+
+const (
+	dotaMaxSearchResults = 5
+
+	// DotaPickCallbackPrefix prefixes the callback data of the buttons sent
+	// to confirm which account a search referred to.
+	DotaPickCallbackPrefix = "dota_pick:"
+)
+
+var DotaSearchPlayerDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
+	Name:        "dota_search_player",
+	Description: "Searches dota players by in-game nickname. When more than one account matches, the user is asked to pick one and their choice is sent back as a new message, so wait for it before calling other dota tools.",
+	Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"name": &genai.Schema{Type: genai.TypeString, Description: "The nickname to search for"},
+		},
+		Required: []string{"name"},
+	},
+}
+
+type dotaSearchResponse struct {
+	AccountId     float64 `json:"account_id"`
+	Personaname   string  `json:"personaname"`
+	Avatarfull    string  `json:"avatarfull"`
+	LastMatchTime string  `json:"last_match_time"`
+	Similarity    float64 `json:"similarity"`
+}
+
+// DotaSearchPlayer looks players up by name. When there are several, question
+// is sent with a button for each so the user can pick the right one.
+func DotaSearchPlayer(ctx context.Context, b *bot.Bot, update *models.Update, name string, question string) CallResponse {
+	log.Println("Searching dota players named", name)
+
+	callResponse := map[string]any{}
+
+	items := []dotaSearchResponse{}
+	err := openDotaGet(ctx, "search", url.Values{"q": {name}}, &items)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	items = items[:min(len(items), dotaMaxSearchResults)]
+
+	candidates := []any{}
+	keyboard := [][]models.InlineKeyboardButton{}
+	for _, item := range items {
+		accountId := fmt.Sprintf("%.0f", item.AccountId)
+		candidate := map[string]any{
+			"account_id": accountId,
+			"name":       item.Personaname,
+			"avatar":     item.Avatarfull,
+		}
+
+		label := fmt.Sprintf("%s (%s)", item.Personaname, accountId)
+		if lastMatch, err := time.Parse(time.RFC3339, item.LastMatchTime); err == nil {
+			candidate["last_match_time"] = lastMatch.Format(time.RFC3339)
+			label += ", " + lastMatch.Format("02/01/2006")
+		}
+
+		candidates = append(candidates, candidate)
+		keyboard = append(keyboard, []models.InlineKeyboardButton{
+			{Text: label, CallbackData: DotaPickCallbackPrefix + accountId},
+		})
+	}
+
+	callResponse["candidates"] = candidates
+	if len(items) < 2 {
+		return callResponse
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:          update.Message.Chat.ID,
+		Text:            question,
+		ReplyParameters: &models.ReplyParameters{MessageID: update.Message.ID},
+		ReplyMarkup:     &models.InlineKeyboardMarkup{InlineKeyboard: keyboard},
+	})
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	callResponse["awaiting_confirmation"] = true
+	return callResponse
+}
@@ -0,0 +1,288 @@
+package capabilities
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+const dotaMaxStatsEntries = 25
+
+var DotaPlayerWinLossDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
+	Name:        "dota_player_win_loss",
+	Description: "Gets the total wins, losses and win rate of a dota player. Optional filters narrow down the matches counted.",
+	Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: dotaFilterProperties(map[string]*genai.Schema{
+			"playerId": &genai.Schema{Type: genai.TypeString, Description: "The player account ID, SteamID or profile URL"},
+			"limit":    &genai.Schema{Type: genai.TypeInteger, Description: "Only count the last N matches"},
+		}),
+		Required: []string{"playerId"},
+	},
+}
+
+type dotaWinLossResponse struct {
+	Win  float64 `json:"win"`
+	Lose float64 `json:"lose"`
+}
+
+func DotaPlayerWinLoss(ctx context.Context, playerId string, filters DotaMatchFilters) CallResponse {
+	log.Println("Getting dota win/loss for player", playerId)
+
+	callResponse := map[string]any{}
+	playerId, err := ResolveDotaAccount(playerId)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	wl := dotaWinLossResponse{}
+	err = openDotaGet(ctx, fmt.Sprintf("players/%s/wl", playerId), filters.query(), &wl)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	callResponse["wins"] = wl.Win
+	callResponse["losses"] = wl.Lose
+	callResponse["matches"] = wl.Win + wl.Lose
+	callResponse["win_rate"] = winRate(wl.Win, wl.Win+wl.Lose)
+	return callResponse
+}
+
+var DotaPlayerHeroesDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
+	Name:        "dota_player_heroes",
+	Description: "Gets a dota player's performance on each hero: games, wins and win rate. Useful to find someone's best or most played heroes.",
+	Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: dotaFilterProperties(map[string]*genai.Schema{
+			"playerId": &genai.Schema{Type: genai.TypeString, Description: "The player account ID, SteamID or profile URL"},
+			"count":    &genai.Schema{Type: genai.TypeInteger, Description: fmt.Sprintf("The number of heroes to return, not higher than %d", dotaMaxStatsEntries)},
+			"sortBy":   &genai.Schema{Type: genai.TypeString, Enum: []string{"games", "win_rate"}, Description: "How to rank the heroes, defaults to games"},
+			"minGames": &genai.Schema{Type: genai.TypeInteger, Description: "Ignore heroes with fewer games than this, useful when sorting by win rate"},
+		}),
+		Required: []string{"playerId"},
+	},
+}
+
+type dotaPlayerHeroResponse struct {
+	HeroId     float64 `json:"hero_id"`
+	LastPlayed float64 `json:"last_played"`
+	Games      float64 `json:"games"`
+	Win        float64 `json:"win"`
+}
+
+func DotaPlayerHeroes(ctx context.Context, playerId string, filters DotaMatchFilters, count int, sortBy string, minGames int) CallResponse {
+	log.Println("Getting dota heroes for player", playerId)
+
+	callResponse := map[string]any{}
+	playerId, err := ResolveDotaAccount(playerId)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	items := []dotaPlayerHeroResponse{}
+	err = openDotaGet(ctx, fmt.Sprintf("players/%s/heroes", playerId), filters.query(), &items)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	items = slices.DeleteFunc(items, func(item dotaPlayerHeroResponse) bool {
+		return item.Games == 0 || item.Games < float64(minGames)
+	})
+
+	if sortBy == "win_rate" {
+		slices.SortStableFunc(items, func(a, b dotaPlayerHeroResponse) int {
+			return cmp.Compare(b.Win/b.Games, a.Win/a.Games)
+		})
+	}
+
+	parsedItems := []any{}
+	for _, item := range items[:min(len(items), clampEntries(count))] {
+		parsedItems = append(parsedItems, map[string]any{
+			"hero":        dotaHeroName(item.HeroId),
+			"games":       item.Games,
+			"wins":        item.Win,
+			"win_rate":    winRate(item.Win, item.Games),
+			"last_played": time.Unix(int64(item.LastPlayed), 0).Format(time.RFC3339),
+		})
+	}
+
+	callResponse["heroes"] = parsedItems
+	return callResponse
+}
+
+var DotaPlayerPeersDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
+	Name:        "dota_player_peers",
+	Description: "Gets the players a dota player most frequently plays with, and the results when playing together.",
+	Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: dotaFilterProperties(map[string]*genai.Schema{
+			"playerId": &genai.Schema{Type: genai.TypeString, Description: "The player account ID, SteamID or profile URL"},
+			"count":    &genai.Schema{Type: genai.TypeInteger, Description: fmt.Sprintf("The number of teammates to return, not higher than %d", dotaMaxStatsEntries)},
+			"sortBy":   &genai.Schema{Type: genai.TypeString, Enum: []string{"games", "win_rate"}, Description: "How to rank the teammates, defaults to games"},
+			"minGames": &genai.Schema{Type: genai.TypeInteger, Description: "Ignore teammates with fewer games together than this, useful when sorting by win rate"},
+		}),
+		Required: []string{"playerId"},
+	},
+}
+
+type dotaPlayerPeerResponse struct {
+	AccountId    float64 `json:"account_id"`
+	Personaname  string  `json:"personaname"`
+	LastPlayed   float64 `json:"last_played"`
+	WithGames    float64 `json:"with_games"`
+	WithWin      float64 `json:"with_win"`
+	AgainstGames float64 `json:"against_games"`
+	AgainstWin   float64 `json:"against_win"`
+}
+
+func DotaPlayerPeers(ctx context.Context, playerId string, filters DotaMatchFilters, count int, sortBy string, minGames int) CallResponse {
+	log.Println("Getting dota peers for player", playerId)
+
+	callResponse := map[string]any{}
+	playerId, err := ResolveDotaAccount(playerId)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	items := []dotaPlayerPeerResponse{}
+	err = openDotaGet(ctx, fmt.Sprintf("players/%s/peers", playerId), filters.query(), &items)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	items = slices.DeleteFunc(items, func(item dotaPlayerPeerResponse) bool {
+		return item.WithGames == 0 || item.WithGames < float64(minGames)
+	})
+
+	if sortBy == "win_rate" {
+		slices.SortStableFunc(items, func(a, b dotaPlayerPeerResponse) int {
+			return cmp.Compare(b.WithWin/b.WithGames, a.WithWin/a.WithGames)
+		})
+	}
+
+	parsedItems := []any{}
+	for _, item := range items[:min(len(items), clampEntries(count))] {
+		parsedItems = append(parsedItems, map[string]any{
+			"account_id":       fmt.Sprintf("%.0f", item.AccountId),
+			"name":             item.Personaname,
+			"games_together":   item.WithGames,
+			"wins_together":    item.WithWin,
+			"win_rate":         winRate(item.WithWin, item.WithGames),
+			"games_against":    item.AgainstGames,
+			"wins_against":     item.AgainstWin,
+			"last_played_with": time.Unix(int64(item.LastPlayed), 0).Format(time.RFC3339),
+		})
+	}
+
+	callResponse["peers"] = parsedItems
+	return callResponse
+}
+
+var DotaPlayerRecordsDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
+	Name:        "dota_player_records",
+	Description: "Gets a dota player's personal records (most kills, highest GPM, longest match, ...) and the match where each happened.",
+	Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: dotaFilterProperties(map[string]*genai.Schema{
+			"playerId": &genai.Schema{Type: genai.TypeString, Description: "The player account ID, SteamID or profile URL"},
+		}),
+		Required: []string{"playerId"},
+	},
+}
+
+var dotaRecordFields []string = []string{
+	"kills",
+	"deaths",
+	"assists",
+	"gold_per_min",
+	"xp_per_min",
+	"last_hits",
+	"denies",
+	"hero_damage",
+	"tower_damage",
+	"hero_healing",
+	"duration",
+}
+
+// DotaPlayerRecords takes, for every record field, the player's best match
+// sorted by that field.
+func DotaPlayerRecords(ctx context.Context, playerId string, filters DotaMatchFilters) CallResponse {
+	log.Println("Getting dota records for player", playerId)
+
+	callResponse := map[string]any{}
+	playerId, err := ResolveDotaAccount(playerId)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	records := make([]map[string]any, len(dotaRecordFields))
+	err = openDotaEach(len(dotaRecordFields), 1, func(i int) error {
+		field := dotaRecordFields[i]
+
+		query := filters.query()
+		query.Set("sort", field)
+		query.Set("limit", "1")
+		query.Add("project", field)
+		query.Add("project", "hero_id")
+		query.Add("project", "start_time")
+
+		items := []map[string]any{}
+		err := openDotaGet(ctx, fmt.Sprintf("players/%s/matches", playerId), query, &items)
+		if err != nil || len(items) == 0 {
+			return err
+		}
+
+		heroId, _ := items[0]["hero_id"].(float64)
+		startTime, _ := items[0]["start_time"].(float64)
+		records[i] = map[string]any{
+			"value":      items[0][field],
+			"match_id":   items[0]["match_id"],
+			"hero":       dotaHeroName(heroId),
+			"start_time": time.Unix(int64(startTime), 0).Format(time.RFC3339),
+		}
+		return nil
+	})
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	parsedRecords := map[string]any{}
+	for i, field := range dotaRecordFields {
+		if records[i] != nil {
+			parsedRecords[field] = records[i]
+		}
+	}
+
+	callResponse["records"] = parsedRecords
+	return callResponse
+}
+
+func winRate(wins float64, games float64) float64 {
+	if games == 0 {
+		return 0
+	}
+
+	return math.Round(wins/games*1000) / 10
+}
+
+func clampEntries(count int) int {
+	if count <= 0 {
+		return 10
+	}
+
+	return min(count, dotaMaxStatsEntries)
+}
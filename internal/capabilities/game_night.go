@@ -0,0 +1,49 @@
+package capabilities
+
+import (
+	"context"
+	"log"
+
+	"github.com/go-telegram/bot"
+	"github.com/victormamede/benebott/internal/gamenight"
+	"google.golang.org/genai"
+)
+
+var StartGameNightDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
+	Name:        "start_game_night",
+	Description: "Starts planning a game night in the chat: sends a poll with the time slots, announces the most voted one and reminds the attendees before it starts.",
+	Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"slots": &genai.Schema{
+				Type:        genai.TypeArray,
+				Items:       &genai.Schema{Type: genai.TypeString},
+				Description: "The time options, formatted like 21:00 for the next occurrence of that hour or 25/12 20:30 for a specific day",
+			},
+		},
+		Required: []string{"slots"},
+	},
+}
+
+// StartGameNight sends the poll of a game night with the time slots in
+// values.
+func StartGameNight(ctx context.Context, b *bot.Bot, chatID int64, planner *gamenight.Planner, values []string) CallResponse {
+	log.Println("Starting game night with slots", values)
+
+	callResponse := map[string]any{}
+	slots, err := planner.ParseSlots(values)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	err = planner.Start(ctx, b, chatID, slots)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	callResponse["started"] = true
+	callResponse["poll_sent"] = true
+	return callResponse
+}
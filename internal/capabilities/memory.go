@@ -0,0 +1,159 @@
+package capabilities
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/victormamede/benebott/internal/memory"
+	"google.golang.org/genai"
+)
+
+var RememberFactDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
+	Name:        "remember_fact",
+	Description: "Saves a lasting fact about a member of the chat, like their main hero or their birthday, so it can be recalled in future conversations. Only save facts the person would be fine with the group knowing.",
+	Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"userId": &genai.Schema{
+				Type:        genai.TypeString,
+				Description: "Telegram ID of the person the fact is about, defaults to the author of the message",
+			},
+			"fact": &genai.Schema{
+				Type:        genai.TypeString,
+				Description: "The fact, written as a short standalone sentence, e.g. \"mains Pudge\"",
+			},
+		},
+		Required: []string{"fact"},
+	},
+}
+
+var RecallFactsDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
+	Name:        "recall_facts",
+	Description: "Lists the facts remembered about a member of the chat, or about everyone when no user is given.",
+	Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"userId": &genai.Schema{
+				Type:        genai.TypeString,
+				Description: "Telegram ID of the person, leave empty for everyone",
+			},
+		},
+	},
+}
+
+var ForgetFactDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
+	Name:        "forget_fact",
+	Description: "Deletes a remembered fact, use recall_facts first to find its id.",
+	Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"userId": &genai.Schema{
+				Type:        genai.TypeString,
+				Description: "Telegram ID of the person the fact is about, defaults to the author of the message",
+			},
+			"factId": &genai.Schema{
+				Type:        genai.TypeInteger,
+				Description: "The id of the fact",
+			},
+		},
+		Required: []string{"factId"},
+	},
+}
+
+// memoryUser parses userId, defaulting to the author of message.
+func memoryUser(message *models.Message, userId string) (int64, error) {
+	if userId = strings.TrimSpace(userId); userId != "" {
+		return strconv.ParseInt(userId, 10, 64)
+	}
+	if message.From == nil {
+		return 0, errors.New("the author of the message is unknown, give a userId")
+	}
+
+	return message.From.ID, nil
+}
+
+func factsResponse(facts []memory.Fact) []any {
+	items := []any{}
+	for _, fact := range facts {
+		items = append(items, map[string]any{
+			"id":      fact.ID,
+			"fact":    fact.Text,
+			"learned": fact.CreatedAt.Format("2006-01-02"),
+		})
+	}
+
+	return items
+}
+
+func RememberFact(memories *memory.Memory, message *models.Message, userId string, fact string) CallResponse {
+	log.Println("Remembering fact about", userId, fact)
+
+	callResponse := map[string]any{}
+	userID, err := memoryUser(message, userId)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	remembered, err := memories.Remember(message.Chat.ID, userID, fact)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	callResponse["remembered"] = true
+	callResponse["id"] = remembered.ID
+	return callResponse
+}
+
+// RecallFacts lists the facts about userId, or about everyone in the chat
+// when it is empty.
+func RecallFacts(memories *memory.Memory, message *models.Message, userId string) CallResponse {
+	log.Println("Recalling facts about", userId)
+
+	callResponse := map[string]any{}
+	if strings.TrimSpace(userId) == "" {
+		people := map[string]any{}
+		for userID, facts := range memories.RecallChat(message.Chat.ID) {
+			people[strconv.FormatInt(userID, 10)] = factsResponse(facts)
+		}
+		callResponse["people"] = people
+		return callResponse
+	}
+
+	userID, err := memoryUser(message, userId)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	callResponse["facts"] = factsResponse(memories.Recall(message.Chat.ID, userID))
+	return callResponse
+}
+
+func ForgetFact(memories *memory.Memory, message *models.Message, userId string, factId int) CallResponse {
+	log.Println("Forgetting fact", factId, "about", userId)
+
+	callResponse := map[string]any{}
+	userID, err := memoryUser(message, userId)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	found, err := memories.Forget(message.Chat.ID, userID, factId)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+	if !found {
+		callResponse["error"] = "there is no fact with this id"
+		return callResponse
+	}
+
+	callResponse["forgotten"] = true
+	return callResponse
+}
@@ -0,0 +1,351 @@
+package capabilities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"google.golang.org/genai"
+)
+
+const (
+	openDotaURL          = "https://api.opendota.com/api/"
+	openDotaDefaultDelay = time.Minute
+	openDotaParseTimeout = 2 * time.Minute
+	openDotaParseEvery   = 5 * time.Second
+	// Most requests sent at once by the tools that need several
+	openDotaConcurrency = 3
+)
+
+var ErrOpenDotaRateLimited = errors.New("rate limited by OpenDota")
+
+// openDotaQuota tracks the remaining requests reported by OpenDota so that
+// requests stop before hitting the limit instead of failing with a 429.
+var openDotaQuota struct {
+	sync.Mutex
+	remainingMinute int
+	remainingDay    int
+	// the remaining counts are unknown past these, until the next response
+	minuteEnds   time.Time
+	dayEnds      time.Time
+	blockedUntil time.Time
+}
+
+func openDotaGet(ctx context.Context, path string, query url.Values, out any) error {
+	return openDotaRequest(ctx, http.MethodGet, path, query, out)
+}
+
+func openDotaRequest(ctx context.Context, method string, path string, query url.Values, out any) error {
+	if err := openDotaCheckQuota(1); err != nil {
+		return err
+	}
+
+	if query == nil {
+		query = url.Values{}
+	}
+	if key := viper.GetString("keys.opendota"); key != "" {
+		query.Set("api_key", key)
+	}
+
+	endpoint := openDotaURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	openDotaUpdateQuota(response)
+
+	responseData, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode == http.StatusTooManyRequests {
+		// Without usable headers the quota may not block, still never treat it as a success
+		if err := openDotaCheckQuota(1); err != nil {
+			return err
+		}
+		return ErrOpenDotaRateLimited
+	}
+	if response.StatusCode >= 400 {
+		apiError := struct {
+			Error string `json:"error"`
+		}{}
+		json.Unmarshal(responseData, &apiError)
+		if apiError.Error == "" {
+			apiError.Error = http.StatusText(response.StatusCode)
+		}
+
+		return fmt.Errorf("opendota: %s", apiError.Error)
+	}
+
+	return json.Unmarshal(responseData, out)
+}
+
+// openDotaCheckQuota fails when OpenDota is blocking requests or when it
+// reported fewer than requests left.
+func openDotaCheckQuota(requests int) error {
+	openDotaQuota.Lock()
+	defer openDotaQuota.Unlock()
+
+	now := time.Now()
+	wait := openDotaQuota.blockedUntil.Sub(now)
+	if now.Before(openDotaQuota.dayEnds) && openDotaQuota.remainingDay < requests {
+		wait = max(wait, openDotaQuota.dayEnds.Sub(now))
+	} else if now.Before(openDotaQuota.minuteEnds) && openDotaQuota.remainingMinute < requests {
+		wait = max(wait, openDotaQuota.minuteEnds.Sub(now))
+	}
+
+	if wait > 0 {
+		return fmt.Errorf("%w, try again in %d seconds", ErrOpenDotaRateLimited, int(wait.Seconds())+1)
+	}
+
+	return nil
+}
+
+// openDotaEach calls fetch for every index below count, with at most
+// openDotaConcurrency calls running at once. Each call sends up to requests
+// requests, the quota is checked for all of them before any is sent so that
+// the tool fails right away instead of halfway through. The first error in
+// index order is returned.
+func openDotaEach(count int, requests int, fetch func(i int) error) error {
+	if err := openDotaCheckQuota(count * requests); err != nil {
+		return err
+	}
+
+	errs := make([]error, count)
+	running := make(chan struct{}, openDotaConcurrency)
+
+	var wg sync.WaitGroup
+	for i := range count {
+		wg.Add(1)
+		running <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-running }()
+
+			errs[i] = fetch(i)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func openDotaUpdateQuota(response *http.Response) {
+	openDotaQuota.Lock()
+	defer openDotaQuota.Unlock()
+
+	if remaining, err := strconv.Atoi(response.Header.Get("X-Rate-Limit-Remaining-Minute")); err == nil {
+		openDotaQuota.remainingMinute = remaining
+		openDotaQuota.minuteEnds = time.Now().Truncate(time.Minute).Add(time.Minute)
+		if remaining <= 0 {
+			openDotaQuota.blockedUntil = openDotaQuota.minuteEnds
+		}
+	}
+
+	if remaining, err := strconv.Atoi(response.Header.Get("X-Rate-Limit-Remaining-Day")); err == nil {
+		openDotaQuota.remainingDay = remaining
+		openDotaQuota.dayEnds = time.Now().Truncate(24 * time.Hour).Add(24 * time.Hour)
+		if remaining <= 0 {
+			openDotaQuota.blockedUntil = openDotaQuota.dayEnds
+		}
+	}
+
+	if response.StatusCode == http.StatusTooManyRequests {
+		delay := openDotaDefaultDelay
+		if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil {
+			delay = time.Duration(seconds) * time.Second
+		}
+
+		openDotaQuota.blockedUntil = time.Now().Add(delay)
+	}
+}
+
+type openDotaProvider struct{}
+
+func (openDotaProvider) Name() string {
+	return "opendota"
+}
+
+func (openDotaProvider) Player(ctx context.Context, accountId string) (DotaPlayer, error) {
+	account := map[string]any{}
+	err := openDotaGet(ctx, fmt.Sprintf("players/%s", accountId), nil, &account)
+	if err != nil {
+		return DotaPlayer{}, err
+	}
+
+	profile, _ := account["profile"].(map[string]any)
+	if id, _ := profile["account_id"].(float64); id == 0 {
+		return DotaPlayer{}, fmt.Errorf("player %s not found or private", accountId)
+	}
+
+	name, _ := profile["personaname"].(string)
+	avatar, _ := profile["avatarfull"].(string)
+	profileURL, _ := profile["profileurl"].(string)
+	rankTier, _ := account["rank_tier"].(float64)
+	leaderboardRank, _ := account["leaderboard_rank"].(float64)
+
+	return DotaPlayer{
+		AccountId:       accountId,
+		Name:            name,
+		Avatar:          avatar,
+		ProfileURL:      profileURL,
+		RankTier:        int(rankTier),
+		LeaderboardRank: int(leaderboardRank),
+		Account:         account,
+	}, nil
+}
+
+func (openDotaProvider) Matches(ctx context.Context, accountId string, filters DotaMatchFilters) ([]DotaPlayerMatchResponse, error) {
+	items := []DotaPlayerMatchResponse{}
+	err := openDotaGet(ctx, fmt.Sprintf("players/%s/matches", accountId), filters.query(), &items)
+
+	return items, err
+}
+
+func (openDotaProvider) Match(ctx context.Context, matchId string) (dotaMatchResponse, error) {
+	match := dotaMatchResponse{}
+	err := openDotaGet(ctx, fmt.Sprintf("matches/%s", matchId), nil, &match)
+
+	return match, err
+}
+
+func (openDotaProvider) Heroes(ctx context.Context) ([]DotaHeroInfo, error) {
+	response := []struct {
+		Id            int      `json:"id"`
+		Name          string   `json:"name"`
+		LocalizedName string   `json:"localized_name"`
+		PrimaryAttr   string   `json:"primary_attr"`
+		Roles         []string `json:"roles"`
+	}{}
+
+	err := openDotaGet(ctx, "heroes", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []DotaHeroInfo{}
+	for _, item := range response {
+		items = append(items, DotaHeroInfo(item))
+	}
+
+	return items, nil
+}
+
+var DotaRequestParseDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
+	Name:        "dota_request_parse",
+	Description: "Asks OpenDota to parse a dota match replay and waits for it to finish, making detailed match stats available.",
+	Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"matchId": &genai.Schema{Type: genai.TypeString, Description: "The match ID"},
+		},
+		Required: []string{"matchId"},
+	},
+}
+
+type openDotaParseRequestResponse struct {
+	Job struct {
+		JobId float64 `json:"jobId"`
+	} `json:"job"`
+}
+
+func DotaRequestParse(ctx context.Context, matchId string) CallResponse {
+	log.Println("Requesting parse of dota match", matchId)
+
+	callResponse := map[string]any{}
+
+	matchId, err := ParseDotaMatchID(matchId)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+	callResponse["match_id"] = matchId
+
+	request := openDotaParseRequestResponse{}
+	err = openDotaRequest(ctx, http.MethodPost, fmt.Sprintf("request/%s", matchId), nil, &request)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, openDotaParseTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(openDotaParseEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return openDotaParseRunning(callResponse)
+		case <-ticker.C:
+		}
+
+		// The job is removed from the queue once it is done
+		var job any
+		err := openDotaRequest(ctx, http.MethodGet, fmt.Sprintf("request/%.0f", request.Job.JobId), nil, &job)
+		if ctx.Err() != nil {
+			return openDotaParseRunning(callResponse)
+		}
+		if err != nil {
+			callResponse["error"] = err.Error()
+			return callResponse
+		}
+		if job != nil {
+			continue
+		}
+
+		match := struct {
+			Version any `json:"version"`
+		}{}
+		err = openDotaRequest(ctx, http.MethodGet, fmt.Sprintf("matches/%s", matchId), nil, &match)
+		if ctx.Err() != nil {
+			return openDotaParseRunning(callResponse)
+		}
+		if err != nil {
+			callResponse["error"] = err.Error()
+			return callResponse
+		}
+
+		callResponse["parsed"] = match.Version != nil
+		if match.Version == nil {
+			callResponse["status"] = "the replay could not be parsed, it may not be available yet"
+		}
+		return callResponse
+	}
+}
+
+// openDotaParseRunning reports a parse that didn't finish before the timeout,
+// which isn't an error since OpenDota keeps working on it.
+func openDotaParseRunning(callResponse map[string]any) CallResponse {
+	callResponse["parsed"] = false
+	callResponse["status"] = "the parse is still running, try again later"
+	return callResponse
+}
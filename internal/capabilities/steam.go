@@ -0,0 +1,170 @@
+package capabilities
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+	"google.golang.org/genai"
+)
+
+// steamID64Base is the SteamID64 of account 0 of an individual public account.
+const steamID64Base uint64 = 76561197960265728
+
+var (
+	steamID3Pattern = regexp.MustCompile(`^\[?U:1:(\d+)\]?$`)
+	steamID2Pattern = regexp.MustCompile(`^STEAM_[0-5]:([01]):(\d+)$`)
+)
+
+var SteamIdConvertDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
+	Name:        "steam_id_convert",
+	Description: "Converts between SteamID64, SteamID3, SteamID2 and dota account IDs. Also extracts IDs from steamcommunity, Dotabuff, OpenDota and STRATZ profile or match links.",
+	Parameters: &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"input": &genai.Schema{Type: genai.TypeString, Description: "An ID in any format or a profile or match URL"},
+		},
+		Required: []string{"input"},
+	},
+}
+
+func SteamIdConvert(input string) CallResponse {
+	callResponse := map[string]any{}
+
+	if matchId, ok := parseDotaMatchURL(input); ok {
+		callResponse["match_id"] = matchId
+		return callResponse
+	}
+
+	accountId, err := ParseSteamID(input)
+	if err != nil {
+		callResponse["error"] = err.Error()
+		return callResponse
+	}
+
+	steamId64 := steamID64Base + uint64(accountId)
+	callResponse["account_id"] = strconv.FormatUint(uint64(accountId), 10)
+	callResponse["steam_id64"] = strconv.FormatUint(steamId64, 10)
+	callResponse["steam_id3"] = fmt.Sprintf("[U:1:%d]", accountId)
+	callResponse["steam_id2"] = fmt.Sprintf("STEAM_1:%d:%d", accountId%2, accountId/2)
+	callResponse["steam_profile"] = fmt.Sprintf("https://steamcommunity.com/profiles/%d", steamId64)
+	callResponse["opendota_profile"] = fmt.Sprintf("https://www.opendota.com/players/%d", accountId)
+	return callResponse
+}
+
+// ParseSteamID extracts the 32-bit dota account ID from an account ID,
+// SteamID64, SteamID3, SteamID2 or a profile URL.
+func ParseSteamID(input string) (uint32, error) {
+	input = strings.TrimSpace(input)
+
+	if u, err := url.Parse(input); err == nil && u.Host != "" {
+		return parseProfileURL(u)
+	}
+	if strings.Contains(input, "/") && !strings.HasPrefix(input, "[") {
+		if u, err := url.Parse("https://" + input); err == nil {
+			return parseProfileURL(u)
+		}
+	}
+
+	if match := steamID3Pattern.FindStringSubmatch(input); match != nil {
+		id, err := strconv.ParseUint(match[1], 10, 32)
+		return uint32(id), err
+	}
+
+	if match := steamID2Pattern.FindStringSubmatch(input); match != nil {
+		y, _ := strconv.ParseUint(match[1], 10, 32)
+		z, err := strconv.ParseUint(match[2], 10, 32)
+		if err != nil || z*2+y > math.MaxUint32 {
+			return 0, fmt.Errorf("%q is out of the steam account ID range", input)
+		}
+		return uint32(z*2 + y), nil
+	}
+
+	id, err := strconv.ParseUint(input, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a steam or dota account ID", input)
+	}
+	if id >= steamID64Base {
+		if id-steamID64Base > math.MaxUint32 {
+			return 0, fmt.Errorf("%q is out of the SteamID64 range", input)
+		}
+		return uint32(id - steamID64Base), nil
+	}
+	if id > math.MaxUint32 {
+		return 0, fmt.Errorf("%q is not a steam or dota account ID", input)
+	}
+
+	return uint32(id), nil
+}
+
+func parseProfileURL(u *url.URL) (uint32, error) {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+
+	if len(segments) >= 2 {
+		switch {
+		case host == "steamcommunity.com" && segments[0] == "profiles":
+			return ParseSteamID(segments[1])
+		case host == "steamcommunity.com" && segments[0] == "id":
+			return 0, fmt.Errorf("custom steam URLs like %q can't be resolved, ask for the profile number instead", u.String())
+		case (host == "dotabuff.com" || host == "opendota.com" || host == "stratz.com") && segments[0] == "players":
+			return ParseSteamID(segments[1])
+		}
+	}
+
+	return 0, fmt.Errorf("%q is not a known profile URL", u.String())
+}
+
+func parseDotaMatchURL(input string) (string, bool) {
+	u, err := url.Parse(strings.TrimSpace(input))
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) < 2 || segments[0] != "matches" {
+		return "", false
+	}
+	if host != "dotabuff.com" && host != "opendota.com" && host != "stratz.com" {
+		return "", false
+	}
+	if _, err := strconv.ParseUint(segments[1], 10, 64); err != nil {
+		return "", false
+	}
+
+	return segments[1], true
+}
+
+// ParseDotaMatchID accepts a match ID or a Dotabuff, OpenDota or STRATZ match URL.
+func ParseDotaMatchID(input string) (string, error) {
+	if matchId, ok := parseDotaMatchURL(input); ok {
+		return matchId, nil
+	}
+
+	input = strings.TrimSpace(input)
+	if _, err := strconv.ParseUint(input, 10, 64); err != nil {
+		return "", fmt.Errorf("%q is not a dota match ID", input)
+	}
+
+	return input, nil
+}
+
+// ResolveDotaAccount maps a telegram user ID to the dota account linked in
+// the dota.accounts config, anything else is parsed with ParseSteamID.
+func ResolveDotaAccount(id string) (string, error) {
+	if account := viper.GetString("dota.accounts." + strings.TrimSpace(id)); account != "" {
+		id = account
+	}
+
+	accountId, err := ParseSteamID(id)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.FormatUint(uint64(accountId), 10), nil
+}
@@ -0,0 +1,134 @@
+package capabilities
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestParseSteamID(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    uint32
+		wantErr bool
+	}{
+		{"account ID", "86745912", 86745912, false},
+		{"surrounding spaces", " 86745912 ", 86745912, false},
+		{"SteamID64", "76561198047011640", 86745912, false},
+		{"SteamID3", "[U:1:86745912]", 86745912, false},
+		{"SteamID3 without brackets", "U:1:86745912", 86745912, false},
+		{"SteamID2", "STEAM_1:0:43372956", 86745912, false},
+		{"SteamID2 odd account", "STEAM_0:1:43372956", 86745913, false},
+		{"steam profile URL", "https://steamcommunity.com/profiles/76561198047011640", 86745912, false},
+		{"steam profile URL without scheme", "steamcommunity.com/profiles/76561198047011640/", 86745912, false},
+		{"Dotabuff URL", "https://www.dotabuff.com/players/86745912", 86745912, false},
+		{"OpenDota URL", "https://www.opendota.com/players/86745912/matches", 86745912, false},
+		{"STRATZ URL", "https://stratz.com/players/86745912", 86745912, false},
+		{"custom steam URL", "https://steamcommunity.com/id/someone", 0, true},
+		{"unknown URL", "https://example.com/players/86745912", 0, true},
+		{"SteamID64 out of range", "76561202255233024", 0, true},
+		{"account ID out of range", "4294967296", 0, true},
+		{"SteamID3 out of range", "[U:1:4294967296]", 0, true},
+		{"SteamID2 out of range", "STEAM_1:1:2147483648", 0, true},
+		{"past uint64", "99999999999999999999", 0, true},
+		{"empty", "", 0, true},
+		{"name", "someone", 0, true},
+		{"negative", "-5", 0, true},
+		{"SteamID2 bad Y", "STEAM_1:2:43372956", 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseSteamID(test.input)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("ParseSteamID(%q) = %d, want an error", test.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSteamID(%q) error: %v", test.input, err)
+			}
+			if got != test.want {
+				t.Fatalf("ParseSteamID(%q) = %d, want %d", test.input, got, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveDotaAccount(t *testing.T) {
+	viper.Set("dota.accounts", map[string]any{
+		"123456789": "[U:1:86745912]",
+		"987654321": "https://www.dotabuff.com/players/1234",
+	})
+	t.Cleanup(viper.Reset)
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"linked telegram user", "123456789", "86745912", false},
+		{"linked telegram user with a URL", "987654321", "1234", false},
+		{"unlinked account ID", "86745912", "86745912", false},
+		{"SteamID64", "76561198047011640", "86745912", false},
+		{"junk", "someone", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ResolveDotaAccount(test.input)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("ResolveDotaAccount(%q) = %q, want an error", test.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveDotaAccount(%q) error: %v", test.input, err)
+			}
+			if got != test.want {
+				t.Fatalf("ResolveDotaAccount(%q) = %q, want %q", test.input, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseDotaMatchID(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"match ID", "7891234567", "7891234567", false},
+		{"surrounding spaces", " 7891234567 ", "7891234567", false},
+		{"Dotabuff URL", "https://www.dotabuff.com/matches/7891234567/combat", "7891234567", false},
+		{"OpenDota URL", "https://www.opendota.com/matches/7891234567", "7891234567", false},
+		{"STRATZ URL", "https://stratz.com/matches/7891234567", "7891234567", false},
+		{"unknown URL", "https://example.com/matches/7891234567", "", true},
+		{"player URL", "https://www.dotabuff.com/players/86745912", "", true},
+		{"empty", "", "", true},
+		{"junk", "last game", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseDotaMatchID(test.input)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("ParseDotaMatchID(%q) = %q, want an error", test.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDotaMatchID(%q) error: %v", test.input, err)
+			}
+			if got != test.want {
+				t.Fatalf("ParseDotaMatchID(%q) = %q, want %q", test.input, got, test.want)
+			}
+		})
+	}
+}
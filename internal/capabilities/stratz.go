@@ -0,0 +1,425 @@
+package capabilities
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const stratzURL = "https://api.stratz.com/graphql"
+
+// stratzGameModes lists the STRATZ game mode enum in the order of the ids
+// used by Valve and OpenDota.
+var stratzGameModes []string = []string{
+	"NONE", "ALL_PICK", "CAPTAINS_MODE", "RANDOM_DRAFT", "SINGLE_DRAFT", "ALL_RANDOM",
+	"INTRO", "THE_DIRETIDE", "REVERSE_CAPTAINS_MODE", "THE_GREEVILING", "TUTORIAL",
+	"MID_ONLY", "LEAST_PLAYED", "NEW_PLAYER_POOL", "COMPENDIUM_MATCHMAKING", "CUSTOM",
+	"CAPTAINS_DRAFT", "BALANCED_DRAFT", "ABILITY_DRAFT", "EVENT", "ALL_RANDOM_DEATH_MATCH",
+	"SOLO_MID", "ALL_PICK_RANKED", "TURBO", "MUTATION",
+}
+
+var stratzLobbyTypes map[string]int = map[string]int{
+	"UNRANKED":     0,
+	"PRACTICE":     1,
+	"TOURNAMENT":   2,
+	"TUTORIAL":     3,
+	"COOP_VS_BOTS": 4,
+	"TEAM_MATCH":   5,
+	"SOLO_QUEUE":   6,
+	"RANKED":       7,
+	"SOLO_MID":     8,
+	"BATTLE_CUP":   9,
+	"LOCAL_BOTS":   10,
+	"SPECTATOR":    11,
+	"EVENT":        12,
+	"GAUNTLET":     13,
+	"NEW_PLAYER":   14,
+	"FEATURED":     15,
+}
+
+type stratzProvider struct{}
+
+func (stratzProvider) Name() string {
+	return "stratz"
+}
+
+func stratzQuery(ctx context.Context, query string, variables map[string]any, out any) error {
+	key := viper.GetString("keys.stratz")
+	if key == "" {
+		return errors.New("keys.stratz is not configured")
+	}
+
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, stratzURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+key)
+	request.Header.Set("User-Agent", "STRATZ_API")
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	responseData, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode == http.StatusTooManyRequests {
+		return errors.New("rate limited by STRATZ")
+	}
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("stratz: %s", http.StatusText(response.StatusCode))
+	}
+
+	result := struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}{}
+	err = json.Unmarshal(responseData, &result)
+	if err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("stratz: %s", result.Errors[0].Message)
+	}
+
+	return json.Unmarshal(result.Data, out)
+}
+
+func (stratzProvider) Player(ctx context.Context, accountId string) (DotaPlayer, error) {
+	id, err := strconv.ParseInt(accountId, 10, 64)
+	if err != nil {
+		return DotaPlayer{}, err
+	}
+
+	response := struct {
+		Player struct {
+			SteamAccount *struct {
+				Name                  string  `json:"name"`
+				Avatar                string  `json:"avatar"`
+				ProfileUri            string  `json:"profileUri"`
+				SeasonRank            float64 `json:"seasonRank"`
+				SeasonLeaderboardRank float64 `json:"seasonLeaderboardRank"`
+			} `json:"steamAccount"`
+		} `json:"player"`
+	}{}
+
+	err = stratzQuery(ctx, `query Player($id: Long!) {
+		player(steamAccountId: $id) {
+			steamAccount { name avatar profileUri seasonRank seasonLeaderboardRank }
+		}
+	}`, map[string]any{"id": id}, &response)
+	if err != nil {
+		return DotaPlayer{}, err
+	}
+
+	account := response.Player.SteamAccount
+	if account == nil {
+		return DotaPlayer{}, fmt.Errorf("player %s not found", accountId)
+	}
+
+	return DotaPlayer{
+		AccountId:       accountId,
+		Name:            account.Name,
+		Avatar:          account.Avatar,
+		ProfileURL:      account.ProfileUri,
+		RankTier:        int(account.SeasonRank),
+		LeaderboardRank: int(account.SeasonLeaderboardRank),
+		Account: map[string]any{
+			"profile": map[string]any{
+				"account_id":  id,
+				"personaname": account.Name,
+				"avatarfull":  account.Avatar,
+				"profileurl":  account.ProfileUri,
+			},
+			"rank_tier":        account.SeasonRank,
+			"leaderboard_rank": account.SeasonLeaderboardRank,
+		},
+	}, nil
+}
+
+func (stratzProvider) Matches(ctx context.Context, accountId string, filters DotaMatchFilters) ([]DotaPlayerMatchResponse, error) {
+	id, err := strconv.ParseInt(accountId, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	request := map[string]any{"take": filters.Limit}
+	if filters.HeroId != 0 {
+		request["heroIds"] = []int{filters.HeroId}
+	}
+	if filters.Days != 0 {
+		request["startDateTime"] = time.Now().AddDate(0, 0, -filters.Days).Unix()
+	}
+	if filters.GameMode >= 0 {
+		request["gameModeIds"] = []int{filters.GameMode}
+	}
+	if filters.LobbyType >= 0 {
+		request["lobbyTypeIds"] = []int{filters.LobbyType}
+	}
+	if filters.Win != nil {
+		request["isVictory"] = *filters.Win
+	}
+	if filters.WithPlayer != "" {
+		withPlayer, err := strconv.ParseInt(filters.WithPlayer, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		request["withFriendSteamAccountIds"] = []int64{withPlayer}
+	}
+
+	response := struct {
+		Player struct {
+			Matches []struct {
+				Id              float64 `json:"id"`
+				DidRadiantWin   bool    `json:"didRadiantWin"`
+				DurationSeconds float64 `json:"durationSeconds"`
+				StartDateTime   float64 `json:"startDateTime"`
+				GameMode        string  `json:"gameMode"`
+				LobbyType       string  `json:"lobbyType"`
+				Players         []struct {
+					IsRadiant    bool    `json:"isRadiant"`
+					HeroId       float64 `json:"heroId"`
+					Kills        float64 `json:"kills"`
+					Deaths       float64 `json:"deaths"`
+					Assists      float64 `json:"assists"`
+					LeaverStatus string  `json:"leaverStatus"`
+				} `json:"players"`
+			} `json:"matches"`
+		} `json:"player"`
+	}{}
+
+	err = stratzQuery(ctx, `query Matches($id: Long!, $request: PlayerMatchesRequestType!) {
+		player(steamAccountId: $id) {
+			matches(request: $request) {
+				id didRadiantWin durationSeconds startDateTime gameMode lobbyType
+				players(steamAccountId: $id) { isRadiant heroId kills deaths assists leaverStatus }
+			}
+		}
+	}`, map[string]any{"id": id, "request": request}, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []DotaPlayerMatchResponse{}
+	for _, match := range response.Player.Matches {
+		if len(match.Players) == 0 {
+			continue
+		}
+		player := match.Players[0]
+
+		item := DotaPlayerMatchResponse{
+			MatchId:    match.Id,
+			RadiantWin: match.DidRadiantWin,
+			Duration:   match.DurationSeconds,
+			HeroId:     player.HeroId,
+			StartTime:  match.StartDateTime,
+			Kills:      player.Kills,
+			Deaths:     player.Deaths,
+			Assists:    player.Assists,
+			GameMode:   float64(stratzGameMode(match.GameMode)),
+			LobbyType:  float64(stratzLobbyType(match.LobbyType)),
+		}
+		if !player.IsRadiant {
+			item.PlayerSlot = 128
+		}
+		if player.LeaverStatus != "" && player.LeaverStatus != "NONE" {
+			item.LeaverStatus = 1
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (stratzProvider) Match(ctx context.Context, matchId string) (dotaMatchResponse, error) {
+	id, err := strconv.ParseInt(matchId, 10, 64)
+	if err != nil {
+		return dotaMatchResponse{}, err
+	}
+
+	response := struct {
+		Match *struct {
+			Id                   float64   `json:"id"`
+			DidRadiantWin        bool      `json:"didRadiantWin"`
+			DurationSeconds      float64   `json:"durationSeconds"`
+			GameMode             string    `json:"gameMode"`
+			ParsedDateTime       any       `json:"parsedDateTime"`
+			RadiantKills         []float64 `json:"radiantKills"`
+			DireKills            []float64 `json:"direKills"`
+			RadiantNetworthLeads []float64 `json:"radiantNetworthLeads"`
+			Players              []struct {
+				SteamAccountId float64 `json:"steamAccountId"`
+				SteamAccount   *struct {
+					Name string `json:"name"`
+				} `json:"steamAccount"`
+				HeroId      float64 `json:"heroId"`
+				IsRadiant   bool    `json:"isRadiant"`
+				Kills       float64 `json:"kills"`
+				Deaths      float64 `json:"deaths"`
+				Assists     float64 `json:"assists"`
+				HeroDamage  float64 `json:"heroDamage"`
+				TowerDamage float64 `json:"towerDamage"`
+				HeroHealing float64 `json:"heroHealing"`
+				Networth    float64 `json:"networth"`
+				Stats       *struct {
+					KillEvents []struct {
+						Time   float64 `json:"time"`
+						Target float64 `json:"target"`
+					} `json:"killEvents"`
+				} `json:"stats"`
+			} `json:"players"`
+		} `json:"match"`
+	}{}
+
+	err = stratzQuery(ctx, `query Match($id: Long!) {
+		match(id: $id) {
+			id didRadiantWin durationSeconds gameMode parsedDateTime
+			radiantKills direKills radiantNetworthLeads
+			players {
+				steamAccountId steamAccount { name } heroId isRadiant
+				kills deaths assists heroDamage towerDamage heroHealing networth
+				stats { killEvents { time target } }
+			}
+		}
+	}`, map[string]any{"id": id}, &response)
+	if err != nil {
+		return dotaMatchResponse{}, err
+	}
+	if response.Match == nil {
+		return dotaMatchResponse{}, fmt.Errorf("match %s not found", matchId)
+	}
+
+	m := response.Match
+	match := dotaMatchResponse{
+		MatchId:        m.Id,
+		RadiantWin:     m.DidRadiantWin,
+		Duration:       m.DurationSeconds,
+		GameMode:       float64(stratzGameMode(m.GameMode)),
+		Version:        m.ParsedDateTime,
+		RadiantGoldAdv: m.RadiantNetworthLeads,
+	}
+	for _, kills := range m.RadiantKills {
+		match.RadiantScore += kills
+	}
+	for _, kills := range m.DireKills {
+		match.DireScore += kills
+	}
+
+	for _, p := range m.Players {
+		player := dotaMatchPlayer{
+			AccountId:   p.SteamAccountId,
+			HeroId:      p.HeroId,
+			IsRadiant:   p.IsRadiant,
+			Kills:       p.Kills,
+			Deaths:      p.Deaths,
+			Assists:     p.Assists,
+			HeroDamage:  p.HeroDamage,
+			TowerDamage: p.TowerDamage,
+			HeroHealing: p.HeroHealing,
+			NetWorth:    p.Networth,
+		}
+		if p.SteamAccount != nil {
+			player.Personaname = p.SteamAccount.Name
+		}
+		if p.Stats != nil {
+			for _, event := range p.Stats.KillEvents {
+				player.KillsLog = append(player.KillsLog, dotaKillLogEntry{
+					Time: event.Time,
+					Key:  heroes[int(event.Target)].Name,
+				})
+			}
+		}
+
+		match.Players = append(match.Players, player)
+	}
+
+	return match, nil
+}
+
+func (stratzProvider) Heroes(ctx context.Context) ([]DotaHeroInfo, error) {
+	response := struct {
+		Constants struct {
+			Heroes []struct {
+				Id          int    `json:"id"`
+				Name        string `json:"name"`
+				DisplayName string `json:"displayName"`
+				Stats       struct {
+					PrimaryAttribute string `json:"primaryAttribute"`
+				} `json:"stats"`
+				Roles []struct {
+					RoleId string `json:"roleId"`
+				} `json:"roles"`
+			} `json:"heroes"`
+		} `json:"constants"`
+	}{}
+
+	err := stratzQuery(ctx, `{
+		constants {
+			heroes { id name displayName stats { primaryAttribute } roles { roleId } }
+		}
+	}`, nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []DotaHeroInfo{}
+	for _, hero := range response.Constants.Heroes {
+		roles := []string{}
+		for _, role := range hero.Roles {
+			if role.RoleId == "" {
+				continue
+			}
+			roles = append(roles, strings.ToUpper(role.RoleId[:1])+strings.ToLower(role.RoleId[1:]))
+		}
+
+		items = append(items, DotaHeroInfo{
+			Id:            hero.Id,
+			Name:          hero.Name,
+			LocalizedName: hero.DisplayName,
+			PrimaryAttr:   hero.Stats.PrimaryAttribute,
+			Roles:         roles,
+		})
+	}
+
+	return items, nil
+}
+
+func stratzGameMode(mode string) int {
+	if id := slices.Index(stratzGameModes, mode); id >= 0 {
+		return id
+	}
+
+	return 0
+}
+
+// stratzLobbyType maps a STRATZ lobby type to its Valve id. Lobby types added
+// to STRATZ after this list are reported as normal matches.
+func stratzLobbyType(lobby string) int {
+	if id, ok := stratzLobbyTypes[lobby]; ok {
+		return id
+	}
+
+	return 0
+}
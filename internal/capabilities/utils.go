@@ -1,6 +1,7 @@
 package capabilities
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/go-telegram/bot/models"
@@ -39,3 +40,24 @@ var MyIdDeclaration genai.FunctionDeclaration = genai.FunctionDeclaration{
 func MyId(update *models.Update) CallResponse {
 	return CallResponse{"id": update.Message.From.ID}
 }
+
+// IntArg reads an optional integer argument sent by the model.
+func IntArg(args map[string]any, name string, fallback int) int {
+	if value, ok := args[name].(float64); ok {
+		return int(value)
+	}
+
+	return fallback
+}
+
+// StringsArg reads an optional array of strings sent by the model.
+func StringsArg(args map[string]any, name string) []string {
+	values := []string{}
+	if items, ok := args[name].([]any); ok {
+		for _, item := range items {
+			values = append(values, fmt.Sprint(item))
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,234 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	width   = 800
+	height  = 450
+	margin  = 50
+	tickLen = 4
+)
+
+var (
+	background = color.RGBA{0x1e, 0x1f, 0x26, 0xff}
+	foreground = color.RGBA{0xe6, 0xe6, 0xe6, 0xff}
+	grid       = color.RGBA{0x3a, 0x3c, 0x48, 0xff}
+	palette    = []color.RGBA{
+		{0x4c, 0xaf, 0x50, 0xff},
+		{0xe5, 0x39, 0x35, 0xff},
+		{0x42, 0xa5, 0xf5, 0xff},
+		{0xff, 0xb3, 0x00, 0xff},
+		{0xab, 0x47, 0xbc, 0xff},
+	}
+)
+
+type Series struct {
+	Name   string
+	Values []float64
+}
+
+type canvas struct {
+	img  *image.RGBA
+	plot image.Rectangle
+}
+
+func newCanvas(title string, left int) *canvas {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{background}, image.Point{}, draw.Src)
+
+	c := &canvas{img: img, plot: image.Rect(left, margin, width-margin/2, height-margin)}
+	c.text(width/2-textWidth(title)/2, margin/2, title, foreground)
+
+	return c
+}
+
+func (c *canvas) encode() ([]byte, error) {
+	buf := bytes.Buffer{}
+	err := png.Encode(&buf, c.img)
+
+	return buf.Bytes(), err
+}
+
+func (c *canvas) text(x, y int, s string, col color.Color) {
+	d := font.Drawer{
+		Dst:  c.img,
+		Src:  &image.Uniform{col},
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(s)
+}
+
+func textWidth(s string) int {
+	return font.MeasureString(basicfont.Face7x13, s).Round()
+}
+
+func (c *canvas) rect(r image.Rectangle, col color.Color) {
+	draw.Draw(c.img, r, &image.Uniform{col}, image.Point{}, draw.Src)
+}
+
+// line draws a segment with the given thickness using Bresenham's algorithm.
+func (c *canvas) line(x0, y0, x1, y1, thickness int, col color.Color) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+
+	err := dx + dy
+	for {
+		c.rect(image.Rect(x0-thickness/2, y0-thickness/2, x0-thickness/2+thickness, y0-thickness/2+thickness), col)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// yAxis draws horizontal grid lines and labels for the [lo, hi] range and
+// returns a function mapping values to pixel rows.
+func (c *canvas) yAxis(lo, hi float64) func(float64) int {
+	if hi == lo {
+		hi = lo + 1
+	}
+
+	const ticks = 5
+	for i := 0; i <= ticks; i++ {
+		value := lo + (hi-lo)*float64(i)/ticks
+		y := c.plot.Max.Y - int(float64(c.plot.Dy())*float64(i)/ticks)
+
+		c.line(c.plot.Min.X, y, c.plot.Max.X, y, 1, grid)
+		label := formatValue(value)
+		c.text(c.plot.Min.X-tickLen-textWidth(label)-2, y+4, label, foreground)
+	}
+
+	return func(value float64) int {
+		return c.plot.Max.Y - int(float64(c.plot.Dy())*(value-lo)/(hi-lo))
+	}
+}
+
+func (c *canvas) legend(series []Series) {
+	x := c.plot.Min.X
+	for i, s := range series {
+		col := palette[i%len(palette)]
+		c.rect(image.Rect(x, height-18, x+10, height-8), col)
+		c.text(x+14, height-8, s.Name, foreground)
+		x += textWidth(s.Name) + 34
+	}
+}
+
+// Line renders one line per series over the shared x labels.
+func Line(title string, labels []string, series []Series, lo, hi float64) ([]byte, error) {
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("no data to plot")
+	}
+
+	c := newCanvas(title, margin)
+	y := c.yAxis(lo, hi)
+
+	step := float64(c.plot.Dx())
+	if len(labels) > 1 {
+		step /= float64(len(labels) - 1)
+	}
+	x := func(i int) int {
+		return c.plot.Min.X + int(step*float64(i))
+	}
+
+	labelEvery := max(1, len(labels)/10)
+	for i, label := range labels {
+		if i%labelEvery == 0 {
+			c.line(x(i), c.plot.Max.Y, x(i), c.plot.Max.Y+tickLen, 1, foreground)
+			c.text(x(i)-textWidth(label)/2, c.plot.Max.Y+tickLen+13, label, foreground)
+		}
+	}
+
+	for i, s := range series {
+		col := palette[i%len(palette)]
+		for j, value := range s.Values {
+			if j > 0 {
+				c.line(x(j-1), y(s.Values[j-1]), x(j), y(value), 3, col)
+			}
+			c.rect(image.Rect(x(j)-3, y(value)-3, x(j)+3, y(value)+3), col)
+		}
+	}
+
+	c.legend(series)
+	return c.encode()
+}
+
+// HorizontalBar renders one bar per label, the label is drawn left of its bar.
+func HorizontalBar(title string, labels []string, values []float64) ([]byte, error) {
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("no data to plot")
+	}
+
+	left := margin
+	for _, label := range labels {
+		left = max(left, textWidth(label)+16)
+	}
+
+	c := newCanvas(title, left)
+	hi := 0.0
+	for _, value := range values {
+		hi = math.Max(hi, value)
+	}
+	if hi == 0 {
+		hi = 1
+	}
+
+	rowHeight := c.plot.Dy() / len(labels)
+	barHeight := max(2, rowHeight*2/3)
+	for i, label := range labels {
+		col := palette[i%len(palette)]
+		top := c.plot.Min.Y + rowHeight*i + (rowHeight-barHeight)/2
+		barWidth := int(float64(c.plot.Dx()-60) * values[i] / hi)
+		c.rect(image.Rect(c.plot.Min.X, top, c.plot.Min.X+barWidth, top+barHeight), col)
+
+		textY := top + barHeight/2 + 5
+		c.text(c.plot.Min.X-textWidth(label)-8, textY, label, foreground)
+		c.text(c.plot.Min.X+barWidth+6, textY, formatValue(values[i]), foreground)
+	}
+
+	return c.encode()
+}
+
+func formatValue(value float64) string {
+	if value == math.Trunc(value) {
+		return fmt.Sprintf("%.0f", value)
+	}
+
+	return fmt.Sprintf("%.1f", value)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
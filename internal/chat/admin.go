@@ -0,0 +1,33 @@
+package chat
+
+import (
+	"context"
+	"log"
+	"slices"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/spf13/viper"
+)
+
+// isAdmin reports whether the sender can change the bot settings of the chat:
+// anyone in a private chat, the ids in bot.admin_ids and the chat admins.
+func isAdmin(ctx context.Context, b *bot.Bot, message *models.Message) bool {
+	if message.From == nil {
+		return false
+	}
+	if message.Chat.Type == models.ChatTypePrivate {
+		return true
+	}
+	if slices.Contains(viper.GetIntSlice("bot.admin_ids"), int(message.From.ID)) {
+		return true
+	}
+
+	member, err := b.GetChatMember(ctx, &bot.GetChatMemberParams{ChatID: message.Chat.ID, UserID: message.From.ID})
+	if err != nil {
+		log.Println("Get chat member error", err)
+		return false
+	}
+
+	return member.Type == models.ChatMemberTypeOwner || member.Type == models.ChatMemberTypeAdministrator
+}
@@ -0,0 +1,334 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/spf13/viper"
+	"github.com/victormamede/benebott/internal/i18n"
+	"github.com/victormamede/benebott/internal/storage"
+	"google.golang.org/genai"
+)
+
+// Behavior is something Benebott does on its own to a random message. Run
+// reports whether it actually did something to the message.
+type Behavior interface {
+	Name() string
+	Run(ctx context.Context, b *bot.Bot, message *models.Message, s *Services) (bool, error)
+}
+
+var behaviors map[string]Behavior = map[string]Behavior{}
+
+func registerBehavior(behavior Behavior) {
+	behaviors[behavior.Name()] = behavior
+}
+
+func init() {
+	registerBehavior(mockBehavior{})
+	registerBehavior(reactBehavior{})
+	registerBehavior(stickerBehavior{})
+	registerBehavior(oneLinerBehavior{})
+}
+
+// BehaviorNames returns the registered behaviors in a stable order.
+func BehaviorNames() []string {
+	names := []string{}
+	for name := range behaviors {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// Behaviors rolls the random behaviors for every message. Chances come from
+// behaviors.chances and can be overridden per chat, persisted in store.
+type Behaviors struct {
+	store    *storage.Store[map[int64]map[string]float64]
+	location *time.Location
+
+	mu      sync.Mutex
+	lastRun map[[2]int64]time.Time
+}
+
+func NewBehaviors(store *storage.Store[map[int64]map[string]float64], location *time.Location) *Behaviors {
+	return &Behaviors{store: store, location: location, lastRun: map[[2]int64]time.Time{}}
+}
+
+// Chance returns the probability of the behavior in the chat.
+func (e *Behaviors) Chance(chatID int64, name string) float64 {
+	chance, overridden := 0.0, false
+	e.store.View(func(chances map[int64]map[string]float64) {
+		chance, overridden = chances[chatID][name]
+	})
+	if overridden {
+		return chance
+	}
+
+	key := "behaviors.chances." + name
+	if !viper.IsSet(key) && name == "mock" {
+		// Configs from before the behaviors only had the mock
+		return viper.GetFloat64("bot.mock_chance")
+	}
+
+	return viper.GetFloat64(key)
+}
+
+func (e *Behaviors) SetChance(chatID int64, name string, chance float64) error {
+	if _, ok := behaviors[name]; !ok {
+		return i18n.Errorf("behaviors.unknown", name, strings.Join(BehaviorNames(), ", "))
+	}
+	if chance < 0 || chance > 1 {
+		return i18n.Errorf("behaviors.chance")
+	}
+
+	return e.store.Update(func(chances *map[int64]map[string]float64) {
+		if (*chances)[chatID] == nil {
+			(*chances)[chatID] = map[string]float64{}
+		}
+		(*chances)[chatID][name] = chance
+	})
+}
+
+// ResetChance drops the chat override, going back to the configured chance.
+func (e *Behaviors) ResetChance(chatID int64, name string) error {
+	return e.store.Update(func(chances *map[int64]map[string]float64) {
+		delete((*chances)[chatID], name)
+		if len((*chances)[chatID]) == 0 {
+			delete(*chances, chatID)
+		}
+	})
+}
+
+// Pick rolls every behavior, in random order so none is favored, and returns
+// the first that hits. Excluded users, quiet hours and the per-user cooldown
+// skip the roll.
+func (e *Behaviors) Pick(message *models.Message) (Behavior, bool) {
+	if message.From == nil || message.Text == "" {
+		return nil, false
+	}
+	if slices.Contains(viper.GetIntSlice("behaviors.excluded_ids"), int(message.From.ID)) {
+		return nil, false
+	}
+	if e.quiet(time.Now()) {
+		return nil, false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key := [2]int64{message.Chat.ID, message.From.ID}
+	if time.Since(e.lastRun[key]) < viper.GetDuration("behaviors.cooldown") {
+		return nil, false
+	}
+
+	names := BehaviorNames()
+	rand.Shuffle(len(names), func(i, j int) {
+		names[i], names[j] = names[j], names[i]
+	})
+
+	for _, name := range names {
+		if rand.Float64() < e.Chance(message.Chat.ID, name) {
+			return behaviors[name], true
+		}
+	}
+
+	return nil, false
+}
+
+// Ran starts the cooldown of the author of the message, once a picked
+// behavior acted on it.
+func (e *Behaviors) Ran(message *models.Message) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.lastRun[[2]int64{message.Chat.ID, message.From.ID}] = time.Now()
+}
+
+// quiet reports whether now is inside behaviors.quiet_hours, e.g. "23:00-08:00".
+func (e *Behaviors) quiet(now time.Time) bool {
+	hours := viper.GetString("behaviors.quiet_hours")
+	if hours == "" {
+		return false
+	}
+
+	from, to, ok := parseQuietHours(hours)
+	if !ok {
+		return false
+	}
+
+	now = now.In(e.location)
+	minute := now.Hour()*60 + now.Minute()
+	if from <= to {
+		return minute >= from && minute < to
+	}
+
+	return minute >= from || minute < to
+}
+
+func parseQuietHours(hours string) (int, int, bool) {
+	start, end, ok := strings.Cut(hours, "-")
+	if !ok {
+		return 0, 0, false
+	}
+
+	from, err := time.Parse("15:04", strings.TrimSpace(start))
+	if err != nil {
+		return 0, 0, false
+	}
+	to, err := time.Parse("15:04", strings.TrimSpace(end))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return from.Hour()*60 + from.Minute(), to.Hour()*60 + to.Minute(), true
+}
+
+type mockBehavior struct{}
+
+func (mockBehavior) Name() string { return "mock" }
+
+// Run replies with the message in alternating case.
+func (mockBehavior) Run(ctx context.Context, b *bot.Bot, message *models.Message, s *Services) (bool, error) {
+	if len(message.Text) <= 2 {
+		return false, nil
+	}
+
+	mockMessage := []rune{}
+	for i, curr := range message.Text {
+		if i%2 == 0 {
+			mockMessage = append(mockMessage, unicode.ToUpper(curr))
+		} else {
+			mockMessage = append(mockMessage, unicode.ToLower(curr))
+		}
+	}
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:          message.Chat.ID,
+		ReplyParameters: &models.ReplyParameters{MessageID: message.ID},
+		Text:            string(mockMessage),
+	})
+	return err == nil, err
+}
+
+type reactBehavior struct{}
+
+func (reactBehavior) Name() string { return "react" }
+
+// Run reacts with one of behaviors.reactions. Telegram only accepts the
+// emojis of its reaction list.
+func (reactBehavior) Run(ctx context.Context, b *bot.Bot, message *models.Message, s *Services) (bool, error) {
+	emojis := viper.GetStringSlice("behaviors.reactions")
+	if len(emojis) == 0 {
+		return false, nil
+	}
+
+	_, err := b.SetMessageReaction(ctx, &bot.SetMessageReactionParams{
+		ChatID:    message.Chat.ID,
+		MessageID: message.ID,
+		Reaction: []models.ReactionType{{
+			Type:              models.ReactionTypeTypeEmoji,
+			ReactionTypeEmoji: &models.ReactionTypeEmoji{Emoji: emojis[rand.IntN(len(emojis))]},
+		}},
+	})
+	return err == nil, err
+}
+
+type stickerBehavior struct{}
+
+func (stickerBehavior) Name() string { return "sticker" }
+
+// Run replies with one of the sticker file ids in behaviors.stickers.
+func (stickerBehavior) Run(ctx context.Context, b *bot.Bot, message *models.Message, s *Services) (bool, error) {
+	stickers := viper.GetStringSlice("behaviors.stickers")
+	if len(stickers) == 0 {
+		return false, nil
+	}
+
+	_, err := b.SendSticker(ctx, &bot.SendStickerParams{
+		ChatID:          message.Chat.ID,
+		Sticker:         &models.InputFileString{Data: stickers[rand.IntN(len(stickers))]},
+		ReplyParameters: &models.ReplyParameters{MessageID: message.ID},
+	})
+	return err == nil, err
+}
+
+type oneLinerBehavior struct{}
+
+func (oneLinerBehavior) Name() string { return "one_liner" }
+
+// Run asks the model for a short comment on the message, outside of the chat
+// history so it doesn't pollute the conversation.
+func (oneLinerBehavior) Run(ctx context.Context, b *bot.Bot, message *models.Message, s *Services) (bool, error) {
+	config := &genai.GenerateContentConfig{SystemInstruction: s.chatConfig(message.Chat).SystemInstruction}
+
+	history := []*genai.Content{
+		genai.NewContentFromText(s.T(message.Chat.ID, "behaviors.one_liner.instruction"), genai.RoleUser),
+		genai.NewContentFromText(RecentMessage{Name: message.From.FirstName, Text: message.Text}.String(), genai.RoleUser),
+	}
+
+	resp, _, err := generate(ctx, s.AI, s.Settings.Models(message.Chat.ID), history, config)
+	if err != nil {
+		return false, err
+	}
+
+	text := strings.TrimSpace(resp.Text())
+	if text == "" {
+		return false, nil
+	}
+
+	reply(ctx, b, message, text)
+	return true, nil
+}
+
+// BehaviorsCommand lists the chances of the random behaviors in the chat,
+// admins change them with "/behaviors set mock 0.01" and "/behaviors reset mock".
+func BehaviorsCommand(ctx context.Context, b *bot.Bot, update *models.Update, s *Services) {
+	message := update.Message
+	fields := strings.Fields(commandArgs(message.Text))
+
+	if len(fields) == 0 {
+		lines := []string{s.T(message.Chat.ID, "behaviors.title")}
+		for _, name := range BehaviorNames() {
+			lines = append(lines, fmt.Sprintf("%s: %g", name, s.Behaviors.Chance(message.Chat.ID, name)))
+		}
+		reply(ctx, b, message, strings.Join(lines, "\n"))
+		return
+	}
+
+	if !isAdmin(ctx, b, message) {
+		reply(ctx, b, message, s.T(message.Chat.ID, "admin.only"))
+		return
+	}
+
+	var err error
+	switch {
+	case fields[0] == "set" && len(fields) == 3:
+		var chance float64
+		chance, err = strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			err = i18n.Errorf("behaviors.chance")
+			break
+		}
+		err = s.Behaviors.SetChance(message.Chat.ID, fields[1], chance)
+	case fields[0] == "reset" && len(fields) == 2:
+		err = s.Behaviors.ResetChance(message.Chat.ID, fields[1])
+	default:
+		err = i18n.Errorf("behaviors.usage")
+	}
+
+	if err != nil {
+		replyCommandError(ctx, b, message, s, err)
+		return
+	}
+	reply(ctx, b, message, fmt.Sprintf("%s: %g", fields[1], s.Behaviors.Chance(message.Chat.ID, fields[1])))
+}
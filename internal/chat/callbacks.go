@@ -0,0 +1,84 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/victormamede/benebott/internal/capabilities"
+	"google.golang.org/genai"
+)
+
+// callbackMessage answers the query and removes the buttons of its message so
+// they are only used once. It returns nil when the message is too old.
+func callbackMessage(ctx context.Context, b *bot.Bot, query *models.CallbackQuery) *models.Message {
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: query.ID})
+	if err != nil {
+		log.Println("Callback answer error", err)
+	}
+
+	message := query.Message.Message
+	if message == nil {
+		return nil
+	}
+
+	_, err = b.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:    message.Chat.ID,
+		MessageID: message.ID,
+	})
+	if err != nil {
+		log.Println("Edit markup error", err)
+	}
+
+	return message
+}
+
+// callbackCall sends text to the chat conversation. Replies go to the message
+// with the buttons, on behalf of whoever pressed them.
+func callbackCall(ctx context.Context, b *bot.Bot, query *models.CallbackQuery, message *models.Message, s *Services, text string) {
+	callbackUpdate := &models.Update{Message: &models.Message{
+		ID:   message.ID,
+		Chat: message.Chat,
+		From: &query.From,
+	}}
+
+	cs := s.Chats.Get(message.Chat.ID)
+	aiCall(ctx, b, callbackUpdate, s, cs, *genai.NewPartFromText(fmt.Sprintf("[%s] %s", query.From.FirstName, text)))
+}
+
+// DotaPickHandler handles the confirmation buttons sent by the
+// dota_search_player capability, telling the model which account was picked.
+func DotaPickHandler(ctx context.Context, b *bot.Bot, update *models.Update, s *Services) {
+	query := update.CallbackQuery
+	accountId := strings.TrimPrefix(query.Data, capabilities.DotaPickCallbackPrefix)
+
+	message := callbackMessage(ctx, b, query)
+	if message == nil {
+		return
+	}
+
+	callbackCall(ctx, b, query, message, s, "picked the dota account "+accountId)
+}
+
+// ContinueHandler handles the button offered when an answer hit the output
+// token limit, asking the model to keep going. Buttons of answers the
+// conversation moved on from are ignored.
+func ContinueHandler(ctx context.Context, b *bot.Bot, update *models.Update, s *Services) {
+	query := update.CallbackQuery
+
+	message := callbackMessage(ctx, b, query)
+	if message == nil {
+		return
+	}
+
+	messageID, err := strconv.Atoi(strings.TrimPrefix(query.Data, ContinueCallbackPrefix))
+	if err != nil || !s.Chats.Get(message.Chat.ID).Truncated(messageID) {
+		return
+	}
+
+	callbackCall(ctx, b, query, message, s, "asked you to continue your last answer exactly where it stopped")
+}
@@ -2,34 +2,95 @@ package chat
 
 import (
 	"context"
+	"slices"
+	"sync"
 
-	"github.com/spf13/viper"
 	"google.golang.org/genai"
 )
 
+// Conversation is the history of one chat with the model. It keeps the
+// history itself so any model of the fallback chain can continue it.
+type Conversation struct {
+	mu      sync.Mutex
+	history []*genai.Content
+	// truncated is the message whose answer hit the token limit, until the
+	// conversation moves on
+	truncated int
+}
+
+// Send adds part to the conversation and returns the answer along with the
+// model of models that gave it. config and models are passed on every call so
+// prompts rendered per request stay current.
+func (c *Conversation) Send(ctx context.Context, client *genai.Client, config *genai.GenerateContentConfig, models []string, part genai.Part) (*genai.GenerateContentResponse, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.truncated = 0
+	input := &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{&part}}
+	contents := append(slices.Clone(c.history), input)
+
+	resp, model, err := generate(ctx, client, models, contents, config)
+	if err != nil {
+		return nil, model, err
+	}
+
+	// Like genai.Chat, the first candidate is the one kept in the history
+	if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
+		c.history = append(c.history, input, resp.Candidates[0].Content)
+	}
+
+	return resp, model, nil
+}
+
+// Truncate records that the answer to messageID was cut by the token limit.
+func (c *Conversation) Truncate(messageID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.truncated = messageID
+}
+
+// Truncated reports whether the last answer of the conversation is the one
+// to messageID and was cut by the token limit.
+func (c *Conversation) Truncated(messageID int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.truncated != 0 && c.truncated == messageID
+}
+
 type ChatStore struct {
 	MaxHistory int
 
-	chats map[int64]*genai.Chat
+	mu    sync.Mutex
+	chats map[int64]*Conversation
 }
 
 func CreateChatStore(maxHistory int) *ChatStore {
 	return &ChatStore{
-		chats:      map[int64]*genai.Chat{},
+		chats:      map[int64]*Conversation{},
 		MaxHistory: maxHistory,
 	}
 }
 
-func (s *ChatStore) Get(ctx context.Context, id int64, client *genai.Client, config *genai.GenerateContentConfig) *genai.Chat {
-	chat, ok := s.chats[id]
+// Get returns the conversation of the chat, starting one when there is none.
+func (s *ChatStore) Get(id int64) *Conversation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
+	chat, ok := s.chats[id]
 	if !ok {
-		history := []*genai.Content{}
-		chat, _ := client.Chats.Create(ctx, viper.GetString("bot.model"), config, history)
+		chat = &Conversation{}
 		s.chats[id] = chat
-
-		return chat
 	}
 
 	return chat
 }
+
+// Reset drops the conversation of the chat, the next Get starts a fresh one.
+func (s *ChatStore) Reset(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.chats, id)
+}
@@ -0,0 +1,27 @@
+package chat
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// commandArgs returns the text after the command, e.g. "a b" for "/cmd@bot a b".
+func commandArgs(text string) string {
+	_, args, _ := strings.Cut(text, " ")
+	return strings.TrimSpace(args)
+}
+
+func reply(ctx context.Context, b *bot.Bot, message *models.Message, text string) {
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:          message.Chat.ID,
+		Text:            text,
+		ReplyParameters: &models.ReplyParameters{MessageID: message.ID},
+	})
+	if err != nil {
+		log.Println("Reply error", err)
+	}
+}
@@ -0,0 +1,113 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/spf13/viper"
+	"google.golang.org/genai"
+)
+
+// Kinds of failure, each with a friendly reply in the catalog as error.<kind>.
+const (
+	failureQuota    = "quota"
+	failureSafety   = "safety"
+	failureTimeout  = "timeout"
+	failureTool     = "tool"
+	failureTelegram = "telegram"
+	failureUnknown  = "unknown"
+)
+
+var errSafetyBlocked = errors.New("response blocked by the safety filters")
+
+// toolError is a capability that failed so badly it couldn't answer the model.
+type toolError struct {
+	name string
+	err  error
+}
+
+func (e *toolError) Error() string {
+	return fmt.Sprintf("tool %s: %v", e.name, e.err)
+}
+
+func (e *toolError) Unwrap() error {
+	return e.err
+}
+
+var telegramErrors []error = []error{
+	bot.ErrorForbidden, bot.ErrorBadRequest, bot.ErrorUnauthorized,
+	bot.ErrorTooManyRequests, bot.ErrorNotFound, bot.ErrorConflict,
+}
+
+func classifyError(err error) string {
+	var apiErr genai.APIError
+	var netErr net.Error
+	var tool *toolError
+
+	switch {
+	case errors.Is(err, errSafetyBlocked):
+		return failureSafety
+	case errors.As(err, &tool):
+		return failureTool
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return failureTimeout
+	case errors.As(err, &apiErr):
+		switch {
+		case apiErr.Code == http.StatusTooManyRequests || apiErr.Status == "RESOURCE_EXHAUSTED":
+			return failureQuota
+		case apiErr.Code == http.StatusGatewayTimeout || apiErr.Status == "DEADLINE_EXCEEDED":
+			return failureTimeout
+		}
+	case bot.IsTooManyRequestsError(err):
+		return failureTelegram
+	}
+
+	for _, telegramErr := range telegramErrors {
+		if errors.Is(err, telegramErr) {
+			return failureTelegram
+		}
+	}
+
+	return failureUnknown
+}
+
+// replyCommandError replies to a command that failed. Errors meant for users
+// are shown in the chat language, anything else goes through replyError so
+// the raw text only reaches the admin chat.
+func replyCommandError(ctx context.Context, b *bot.Bot, message *models.Message, s *Services, err error) {
+	if text, ok := s.Languages.Error(message.Chat.ID, err); ok {
+		reply(ctx, b, message, s.T(message.Chat.ID, "error", text))
+		return
+	}
+
+	replyError(ctx, b, message, s, err)
+}
+
+// replyError logs the full error and replies to message with a friendly
+// explanation in the chat language. The raw error is forwarded to
+// bot.admin_chat_id when it is set.
+func replyError(ctx context.Context, b *bot.Bot, message *models.Message, s *Services, err error) {
+	kind := classifyError(err)
+	log.Printf("Error (%s) in chat %d, message %d: %v", kind, message.Chat.ID, message.ID, err)
+
+	reply(ctx, b, message, s.T(message.Chat.ID, "error."+kind))
+
+	adminChat := viper.GetInt64("bot.admin_chat_id")
+	if adminChat == 0 || adminChat == message.Chat.ID {
+		return
+	}
+
+	_, sendErr := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: adminChat,
+		Text:   fmt.Sprintf("⚠️ %s error in chat %d (%s):\n%v", kind, message.Chat.ID, message.Chat.Title, err),
+	})
+	if sendErr != nil {
+		log.Println("Admin forward error", sendErr)
+	}
+}
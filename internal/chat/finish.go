@@ -0,0 +1,63 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"google.golang.org/genai"
+)
+
+// ContinueCallbackPrefix starts the data of the button offered when an answer
+// is cut by the output token limit, followed by the id of the answered message.
+const ContinueCallbackPrefix = "continue:"
+
+// promptBlocked reports the reason Gemini refused to answer the prompt at all.
+func promptBlocked(resp *genai.GenerateContentResponse) error {
+	feedback := resp.PromptFeedback
+	if feedback == nil || feedback.BlockReason == "" {
+		return nil
+	}
+
+	return fmt.Errorf("%w: prompt %s %s", errSafetyBlocked, feedback.BlockReason, feedback.BlockReasonMessage)
+}
+
+// replyFinishReason tells the chat why a candidate stopped early, nothing is
+// sent when it finished normally. When part of the answer was already sent
+// only the token limit is reported, the rest would contradict it.
+func replyFinishReason(ctx context.Context, b *bot.Bot, message *models.Message, s *Services, chat *Conversation, reason genai.FinishReason, answered bool) {
+	switch {
+	case reason == "" || reason == genai.FinishReasonStop || reason == genai.FinishReasonUnspecified:
+		return
+	case answered && reason != genai.FinishReasonMaxTokens:
+		log.Println("Answer in chat", message.Chat.ID, "stopped early:", reason)
+		return
+	}
+
+	switch reason {
+	case genai.FinishReasonSafety, genai.FinishReasonBlocklist, genai.FinishReasonProhibitedContent,
+		genai.FinishReasonSPII, genai.FinishReasonImageSafety:
+		replyError(ctx, b, message, s, fmt.Errorf("%w: finish reason %s", errSafetyBlocked, reason))
+	case genai.FinishReasonRecitation:
+		reply(ctx, b, message, s.T(message.Chat.ID, "finish.recitation"))
+	case genai.FinishReasonMaxTokens:
+		chat.Truncate(message.ID)
+		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:          message.Chat.ID,
+			Text:            s.T(message.Chat.ID, "finish.max_tokens"),
+			ReplyParameters: &models.ReplyParameters{MessageID: message.ID},
+			ReplyMarkup: &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{{
+				{Text: s.T(message.Chat.ID, "finish.continue"), CallbackData: ContinueCallbackPrefix + strconv.Itoa(message.ID)},
+			}}},
+		})
+		if err != nil {
+			log.Println("Reply error", err)
+		}
+	default:
+		log.Println("Unexpected finish reason", reason)
+		reply(ctx, b, message, s.T(message.Chat.ID, "finish.other"))
+	}
+}
@@ -0,0 +1,41 @@
+package chat
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// GameNightCommand handles "/gamenight 20:00, 21:30" to start a poll,
+// "/gamenight close" to pick the time now and "/gamenight cancel".
+func GameNightCommand(ctx context.Context, b *bot.Bot, update *models.Update, s *Services) {
+	message := update.Message
+	args := commandArgs(message.Text)
+
+	var err error
+	switch args {
+	case "":
+		reply(ctx, b, message, s.T(message.Chat.ID, "gamenight.usage"))
+		return
+	case "close":
+		err = s.GameNight.Close(ctx, b, message.Chat.ID)
+	case "cancel":
+		err = s.GameNight.Cancel(ctx, b, message.Chat.ID)
+		if err == nil {
+			reply(ctx, b, message, s.T(message.Chat.ID, "gamenight.cancelled"))
+		}
+	default:
+		var slots []time.Time
+		slots, err = s.GameNight.ParseSlots(strings.Split(args, ","))
+		if err == nil {
+			err = s.GameNight.Start(ctx, b, message.Chat.ID, slots)
+		}
+	}
+
+	if err != nil {
+		replyCommandError(ctx, b, message, s, err)
+	}
+}
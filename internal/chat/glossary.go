@@ -0,0 +1,149 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/victormamede/benebott/internal/i18n"
+	"github.com/victormamede/benebott/internal/storage"
+)
+
+// Most terms kept per chat, learned terms are dropped past it
+const maxGlossaryTerms = 200
+
+// Glossary keeps the slang and names of every chat so that they are not
+// mistaken for typos, persisting them in store. Admins curate it with
+// /glossary and the translator adds the terms it learns from the chat.
+type Glossary struct {
+	store *storage.Store[map[int64]map[string]string]
+}
+
+func NewGlossary(store *storage.Store[map[int64]map[string]string]) *Glossary {
+	return &Glossary{store: store}
+}
+
+func (g *Glossary) Add(chatID int64, term string, meaning string) error {
+	return g.store.Update(func(terms *map[int64]map[string]string) {
+		if (*terms)[chatID] == nil {
+			(*terms)[chatID] = map[string]string{}
+		}
+		(*terms)[chatID][strings.ToLower(term)] = meaning
+	})
+}
+
+// Learn adds the terms learned from the chat that are not in its glossary
+// yet, so entries set by admins are never overwritten. It returns the terms
+// added.
+func (g *Glossary) Learn(chatID int64, learned map[string]string) ([]string, error) {
+	added := []string{}
+	err := g.store.Update(func(terms *map[int64]map[string]string) {
+		if (*terms)[chatID] == nil {
+			(*terms)[chatID] = map[string]string{}
+		}
+
+		for term, meaning := range learned {
+			term, meaning = strings.ToLower(strings.TrimSpace(term)), strings.TrimSpace(meaning)
+			if term == "" || meaning == "" || len((*terms)[chatID]) >= maxGlossaryTerms {
+				continue
+			}
+			if _, ok := (*terms)[chatID][term]; ok {
+				continue
+			}
+
+			(*terms)[chatID][term] = meaning
+			added = append(added, term)
+		}
+
+		if len((*terms)[chatID]) == 0 {
+			delete(*terms, chatID)
+		}
+	})
+
+	return added, err
+}
+
+// Remove drops term from the chat glossary, reporting whether it was there.
+func (g *Glossary) Remove(chatID int64, term string) (bool, error) {
+	found := false
+	err := g.store.Update(func(terms *map[int64]map[string]string) {
+		term = strings.ToLower(term)
+		_, found = (*terms)[chatID][term]
+		delete((*terms)[chatID], term)
+		if len((*terms)[chatID]) == 0 {
+			delete(*terms, chatID)
+		}
+	})
+
+	return found, err
+}
+
+// Entries returns the glossary of the chat as sorted "term: meaning" lines.
+func (g *Glossary) Entries(chatID int64) []string {
+	entries := []string{}
+	g.store.View(func(terms map[int64]map[string]string) {
+		for term, meaning := range terms[chatID] {
+			entries = append(entries, fmt.Sprintf("%s: %s", term, meaning))
+		}
+	})
+	slices.Sort(entries)
+
+	return entries
+}
+
+// GlossaryCommand lists the chat glossary, learned terms included. Admins add
+// entries with "/glossary add term = meaning" and drop wrong ones with
+// "/glossary remove term".
+func GlossaryCommand(ctx context.Context, b *bot.Bot, update *models.Update, s *Services) {
+	message := update.Message
+	action, args, _ := strings.Cut(commandArgs(message.Text), " ")
+	args = strings.TrimSpace(args)
+
+	if action == "" {
+		entries := s.Glossary.Entries(message.Chat.ID)
+		if len(entries) == 0 {
+			reply(ctx, b, message, s.T(message.Chat.ID, "glossary.empty"))
+			return
+		}
+		reply(ctx, b, message, s.T(message.Chat.ID, "glossary.title")+"\n"+strings.Join(entries, "\n"))
+		return
+	}
+
+	if !isAdmin(ctx, b, message) {
+		reply(ctx, b, message, s.T(message.Chat.ID, "admin.only"))
+		return
+	}
+
+	var err error
+	switch action {
+	case "add":
+		term, meaning, ok := strings.Cut(args, "=")
+		term, meaning = strings.TrimSpace(term), strings.TrimSpace(meaning)
+		if !ok || term == "" || meaning == "" {
+			err = i18n.Errorf("glossary.usage")
+			break
+		}
+		err = s.Glossary.Add(message.Chat.ID, term, meaning)
+		if err == nil {
+			reply(ctx, b, message, s.T(message.Chat.ID, "glossary.added", term))
+		}
+	case "remove":
+		var found bool
+		found, err = s.Glossary.Remove(message.Chat.ID, args)
+		if err == nil && !found {
+			err = i18n.Errorf("glossary.not_found", args)
+		}
+		if err == nil {
+			reply(ctx, b, message, s.T(message.Chat.ID, "glossary.removed", args))
+		}
+	default:
+		err = i18n.Errorf("error.unknown_option", action, "add, remove")
+	}
+
+	if err != nil {
+		replyCommandError(ctx, b, message, s, err)
+	}
+}
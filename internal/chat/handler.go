@@ -2,20 +2,68 @@ package chat
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
-	"math/rand/v2"
-	"unicode"
+	"time"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
-	"github.com/spf13/viper"
 	"github.com/victormamede/benebott/internal/capabilities"
+	"github.com/victormamede/benebott/internal/gamenight"
+	"github.com/victormamede/benebott/internal/history"
+	"github.com/victormamede/benebott/internal/i18n"
+	"github.com/victormamede/benebott/internal/memory"
 	"google.golang.org/genai"
 )
 
-func Handler(ctx context.Context, b *bot.Bot, update *models.Update, aiClient *genai.Client, config *genai.GenerateContentConfig, store *ChatStore) {
+// Services groups the clients and stores the chat handlers depend on.
+type Services struct {
+	AI            *genai.Client
+	Settings      *Settings
+	Personas      *Personas
+	Location      *time.Location
+	Memory        *memory.Memory
+	History       *history.Index
+	Chats         *ChatStore
+	GameNight     *gamenight.Planner
+	Translator    *Translator
+	Glossary      *Glossary
+	Recent        *RecentMessages
+	Behaviors     *Behaviors
+	Participation *Participation
+	Languages     *i18n.Languages
+}
+
+// T formats the message key in the language of the chat.
+func (s *Services) T(chatID int64, key string, args ...any) string {
+	return s.Languages.T(chatID, key, args...)
+}
+
+// chatConfig returns the model config for the chat from its settings. The
+// system prompt is rendered for this request and asks for answers in the
+// chat language.
+func (s *Services) chatConfig(chat models.Chat) *genai.GenerateContentConfig {
+	settings := s.Settings.Get(chat.ID)
+	prompt := s.renderPrompt(settings.Prompt, chat)
+
+	config := &genai.GenerateContentConfig{
+		SystemInstruction: genai.NewContentFromText(prompt+"\n\n"+s.T(chat.ID, "prompt.language"), genai.RoleUser),
+		Tools:             capabilities.FilterTools(settings.Tools),
+		Temperature:       settings.Temperature,
+	}
+	if settings.ThinkingBudget != nil {
+		config.ThinkingConfig = &genai.ThinkingConfig{ThinkingBudget: settings.ThinkingBudget}
+	}
+
+	return config
+}
+
+func Handler(ctx context.Context, b *bot.Bot, update *models.Update, s *Services) {
+	if update.PollAnswer != nil {
+		s.GameNight.Answer(update.PollAnswer)
+		return
+	}
+
 	botUser, err := b.GetMe(ctx)
 	if err != nil {
 		log.Fatal("Could not get user", err)
@@ -24,69 +72,72 @@ func Handler(ctx context.Context, b *bot.Bot, update *models.Update, aiClient *g
 	if update.Message == nil {
 		return
 	}
+	s.Recent.Add(update.Message)
+	s.History.Add(update.Message)
 
 	if isMentionedOrReplied(botUser, update) {
-		cs := store.Get(ctx, update.Message.Chat.ID, aiClient, config)
-
-		name := "anonymous"
-		if update.Message.From != nil {
-			name = update.Message.From.FirstName
-		}
-
-		aiCall(ctx, b, update, cs, *genai.NewPartFromText(fmt.Sprintf("[%s] %s", name, update.Message.Text)))
-
+		converse(ctx, b, update, s)
 		return
 	}
 
-	if isUnintelligiblePerson(botUser, update) {
-		translateUnintelligible(ctx, b, update, aiClient)
+	if rule, ok := s.Translator.Match(update.Message); ok {
+		translateUnintelligible(ctx, b, update, s, rule)
 		return
 	}
 
-	if rand.Float64() < viper.GetFloat64("bot.mock_chance") && len(update.Message.Text) > 2 {
-		mockMessage := []rune{}
+	join, err := s.Participation.ShouldReply(ctx, update.Message, s)
+	if err != nil {
+		log.Println("Participation error", err)
+	}
+	if join {
+		converse(ctx, b, update, s)
+		return
+	}
 
-		for i, curr := range update.Message.Text {
-			if i%2 == 0 {
-				mockMessage = append(mockMessage, unicode.ToUpper(curr))
-			} else {
-				mockMessage = append(mockMessage, unicode.ToLower(curr))
-			}
+	if behavior, ok := s.Behaviors.Pick(update.Message); ok {
+		acted, err := behavior.Run(ctx, b, update.Message, s)
+		if err != nil {
+			log.Println("Behavior error", behavior.Name(), err)
 		}
+		if acted {
+			s.Behaviors.Ran(update.Message)
+		}
+		return
+	}
+}
 
-		b.SendMessage(ctx,
-			&bot.SendMessageParams{
-				ChatID:          update.Message.Chat.ID,
-				ReplyParameters: &models.ReplyParameters{MessageID: update.Message.ID},
-				Text:            string(mockMessage),
-			})
+// converse sends the message to the chat conversation and replies with the answer.
+func converse(ctx context.Context, b *bot.Bot, update *models.Update, s *Services) {
+	cs := s.Chats.Get(update.Message.Chat.ID)
 
-		return
+	name := "anonymous"
+	if update.Message.From != nil {
+		name = update.Message.From.FirstName
 	}
 
+	aiCall(ctx, b, update, s, cs, *genai.NewPartFromText(fmt.Sprintf("[%s] %s", name, update.Message.Text)))
 }
 
-func aiCall(ctx context.Context, b *bot.Bot, update *models.Update, chat *genai.Chat, part genai.Part) {
+func aiCall(ctx context.Context, b *bot.Bot, update *models.Update, s *Services, chat *Conversation, part genai.Part) {
 	b.SendChatAction(ctx, &bot.SendChatActionParams{ChatID: update.Message.Chat.ID, Action: models.ChatActionTyping})
 
-	resp, err := chat.SendMessage(ctx, part)
+	resp, model, err := chat.Send(ctx, s.AI, s.chatConfig(update.Message.Chat), s.Settings.Models(update.Message.Chat.ID), part)
 
+	if err == nil {
+		err = promptBlocked(resp)
+	}
 	if err != nil {
-		log.Println("Gemini error", err)
-
-		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID:          update.Message.Chat.ID,
-			Text:            "Erro: " + err.Error(),
-			ReplyParameters: &models.ReplyParameters{MessageID: update.Message.ID},
-		})
-
-		if err != nil {
-			log.Println("Reply error", err)
-		}
+		replyError(ctx, b, update.Message, s, err)
+		return
+	}
+	log.Println("Chat", update.Message.Chat.ID, "answered by", model)
+	if len(resp.Candidates) == 0 {
+		replyFinishReason(ctx, b, update.Message, s, chat, genai.FinishReasonOther, false)
 		return
 	}
 
 	for _, cand := range resp.Candidates {
+		answered := false
 		if cand.Content != nil {
 			for _, part := range cand.Content.Parts {
 				if part.Text != "" {
@@ -97,30 +148,22 @@ func aiCall(ctx context.Context, b *bot.Bot, update *models.Update, chat *genai.
 					})
 
 					if err != nil {
-						log.Println("Reply error", err)
+						replyError(ctx, b, update.Message, s, err)
 						continue
 					}
+					answered = true
 
 				} else if part.FunctionCall != nil {
-					response := map[string]any{}
 					v := part.FunctionCall
-
-					switch v.Name {
-					case capabilities.MyIpDeclaration.Name:
-						response = capabilities.MyIp()
-					case capabilities.DotaPlayerAccountDeclaration.Name:
-						response = capabilities.DotaPlayerAccount(v.Args["playerId"].(string))
-					case capabilities.DotaPlayerMatchesDeclaration.Name:
-						response = capabilities.DotaPlayerMatches(v.Args["playerId"].(string), int(v.Args["limit"].(float64)))
-					case capabilities.DotaHeroesDeclaration.Name:
-						response = capabilities.DotaHeroes()
-					case capabilities.UnixTimestampDeclaration.Name:
-						response = capabilities.UnixTimestamp(int64(v.Args["timestamp"].(float64)))
-					case capabilities.MyIdDeclaration.Name:
-						response = capabilities.MyId(update)
+					response, err := callTool(ctx, b, update, s, v)
+					if err != nil {
+						// The history ends with a call that will never be answered
+						s.Chats.Reset(update.Message.Chat.ID)
+						replyError(ctx, b, update.Message, s, err)
+						return
 					}
 
-					aiCall(ctx, b, update, chat, genai.Part{
+					aiCall(ctx, b, update, s, chat, genai.Part{
 						FunctionResponse: &genai.FunctionResponse{
 							Name: v.Name, Response: response,
 						},
@@ -132,91 +175,119 @@ func aiCall(ctx context.Context, b *bot.Bot, update *models.Update, chat *genai.
 				}
 			}
 		}
-	}
 
-}
+		replyFinishReason(ctx, b, update.Message, s, chat, cand.FinishReason, answered)
+	}
 
-type IntelligibleResponse struct {
-	IsCorrect        bool   `json:"isCorrect"`
-	CorrectedVersion string `json:"correctedVersion"`
 }
 
-func translateUnintelligible(ctx context.Context, b *bot.Bot, update *models.Update, aiClient *genai.Client) {
-	config := &genai.GenerateContentConfig{
-		ResponseMIMEType: "application/json",
-		ResponseSchema: &genai.Schema{
-			Type: genai.TypeObject,
-			Properties: map[string]*genai.Schema{
-				"isCorrect": {Type: genai.TypeBoolean, Description: "Wether the message is grammatically correct. (true means correct)"},
-				"correctedVersion": {
-					Description: "The correct form of the message",
-					Type:        genai.TypeString,
-				},
-			},
-			Required: []string{"isCorrect"},
-		},
-	}
-
-	history := []*genai.Content{
-		genai.NewContentFromText(viper.GetString("bot.unintelligible_prompt"), genai.RoleUser),
-		genai.NewContentFromText(fmt.Sprintf("Mensagem: \"%s\"", update.Message.Text), genai.RoleUser),
-	}
-
-	resp, err := aiClient.Models.GenerateContent(
-		ctx,
-		viper.GetString("bot.model"),
-		history,
-		config,
-	)
-
-	if err != nil {
-		log.Println("Gemini error", err)
-
-		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-			ChatID:          update.Message.Chat.ID,
-			Text:            "Erro: " + err.Error(),
-			ReplyParameters: &models.ReplyParameters{MessageID: update.Message.ID},
-		})
-
+// callTool runs the capability the model asked for. Capabilities report their
+// errors to the model, a panic is the only failure returned.
+func callTool(ctx context.Context, b *bot.Bot, update *models.Update, s *Services, v *genai.FunctionCall) (response capabilities.CallResponse, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &toolError{name: v.Name, err: fmt.Errorf("%v", r)}
+		}
+	}()
+
+	response = capabilities.CallResponse{}
+	switch v.Name {
+	case capabilities.MyIpDeclaration.Name:
+		response = capabilities.MyIp()
+	case capabilities.DotaPlayerAccountDeclaration.Name:
+		response = capabilities.DotaPlayerAccount(ctx, v.Args["playerId"].(string))
+	case capabilities.DotaPlayerMatchesDeclaration.Name:
+		filters, err := capabilities.ParseDotaMatchFilters(v.Args)
 		if err != nil {
-			log.Println("Reply error", err)
+			response["error"] = err.Error()
+			break
 		}
-		return
+		response = capabilities.DotaPlayerMatches(ctx, v.Args["playerId"].(string), filters)
+	case capabilities.DotaHeroesDeclaration.Name:
+		response = capabilities.DotaHeroes(ctx)
+	case capabilities.DotaPlayerWinLossDeclaration.Name,
+		capabilities.DotaPlayerHeroesDeclaration.Name,
+		capabilities.DotaPlayerPeersDeclaration.Name,
+		capabilities.DotaPlayerRecordsDeclaration.Name,
+		capabilities.DotaComparePlayersDeclaration.Name:
+		response = dotaStatsCall(ctx, v)
+	case capabilities.DotaPlayerChartDeclaration.Name:
+		filters, err := capabilities.ParseDotaMatchFilters(v.Args)
+		if err != nil {
+			response["error"] = err.Error()
+			break
+		}
+		kind, _ := v.Args["chart"].(string)
+		limit := capabilities.IntArg(v.Args, "limit", 20)
+		response = capabilities.DotaPlayerChart(ctx, b, update, v.Args["playerId"].(string), kind, filters, limit)
+	case capabilities.DotaRequestParseDeclaration.Name:
+		response = capabilities.DotaRequestParse(ctx, v.Args["matchId"].(string))
+	case capabilities.DotaSearchPlayerDeclaration.Name:
+		response = capabilities.DotaSearchPlayer(ctx, b, update, v.Args["name"].(string), s.T(update.Message.Chat.ID, "dota.which_one", v.Args["name"]))
+	case capabilities.SteamIdConvertDeclaration.Name:
+		response = capabilities.SteamIdConvert(v.Args["input"].(string))
+	case capabilities.DotaMatchHighlightsDeclaration.Name:
+		response = capabilities.DotaMatchHighlights(ctx, v.Args["matchId"].(string))
+	case capabilities.DotaRandomHeroDeclaration.Name:
+		role, _ := v.Args["role"].(string)
+		attribute, _ := v.Args["attribute"].(string)
+		playerId, _ := v.Args["playerId"].(string)
+		response = capabilities.DotaRandomHero(
+			ctx, capabilities.IntArg(v.Args, "count", 1), role, attribute,
+			playerId, capabilities.IntArg(v.Args, "recent", 20), capabilities.StringsArg(v.Args, "exclude"),
+		)
+	case capabilities.DotaDraftHelperDeclaration.Name:
+		role, _ := v.Args["role"].(string)
+		response = capabilities.DotaDraftHelper(
+			ctx, capabilities.StringsArg(v.Args, "allies"), capabilities.StringsArg(v.Args, "enemies"),
+			role, capabilities.IntArg(v.Args, "count", 5),
+		)
+	case capabilities.StartGameNightDeclaration.Name:
+		response = capabilities.StartGameNight(ctx, b, update.Message.Chat.ID, s.GameNight, capabilities.StringsArg(v.Args, "slots"))
+	case capabilities.RememberFactDeclaration.Name:
+		userId, _ := v.Args["userId"].(string)
+		fact, _ := v.Args["fact"].(string)
+		response = capabilities.RememberFact(s.Memory, update.Message, userId, fact)
+	case capabilities.RecallFactsDeclaration.Name:
+		userId, _ := v.Args["userId"].(string)
+		response = capabilities.RecallFacts(s.Memory, update.Message, userId)
+	case capabilities.ForgetFactDeclaration.Name:
+		userId, _ := v.Args["userId"].(string)
+		response = capabilities.ForgetFact(s.Memory, update.Message, userId, capabilities.IntArg(v.Args, "factId", 0))
+	case capabilities.SearchChatHistoryDeclaration.Name:
+		query, _ := v.Args["query"].(string)
+		response = capabilities.SearchChatHistory(ctx, s.History, update.Message.Chat.ID, s.Location, query, capabilities.IntArg(v.Args, "limit", 5))
+	case capabilities.UnixTimestampDeclaration.Name:
+		response = capabilities.UnixTimestamp(int64(v.Args["timestamp"].(float64)))
+	case capabilities.MyIdDeclaration.Name:
+		response = capabilities.MyId(update)
 	}
 
-	for _, cand := range resp.Candidates {
-		if cand.Content != nil {
-			for _, part := range cand.Content.Parts {
-
-				response := IntelligibleResponse{}
-				err = json.Unmarshal([]byte(part.Text), &response)
-				if err != nil {
-
-					log.Println("Marshall error:", err)
-					b.SendMessage(ctx, &bot.SendMessageParams{
-						ChatID:          update.Message.Chat.ID,
-						Text:            "Erro: " + err.Error(),
-						ReplyParameters: &models.ReplyParameters{MessageID: update.Message.ID},
-					})
-					return
-				}
-
-				if response.IsCorrect {
-					return
-				}
+	return response, nil
+}
 
-				_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-					ChatID:          update.Message.Chat.ID,
-					Text:            fmt.Sprintf("Tradução: \"%s\"", response.CorrectedVersion),
-					ReplyParameters: &models.ReplyParameters{MessageID: update.Message.ID},
-				})
+func dotaStatsCall(ctx context.Context, call *genai.FunctionCall) capabilities.CallResponse {
+	filters, err := capabilities.ParseDotaMatchFilters(call.Args)
+	if err != nil {
+		return capabilities.CallResponse{"error": err.Error()}
+	}
 
-				if err != nil {
-					log.Println("Reply error", err)
-					continue
-				}
-			}
-		}
+	playerId, _ := call.Args["playerId"].(string)
+	count := capabilities.IntArg(call.Args, "count", 10)
+	sortBy, _ := call.Args["sortBy"].(string)
+	minGames := capabilities.IntArg(call.Args, "minGames", 0)
+
+	switch call.Name {
+	case capabilities.DotaPlayerWinLossDeclaration.Name:
+		return capabilities.DotaPlayerWinLoss(ctx, playerId, filters)
+	case capabilities.DotaPlayerHeroesDeclaration.Name:
+		return capabilities.DotaPlayerHeroes(ctx, playerId, filters, count, sortBy, minGames)
+	case capabilities.DotaPlayerPeersDeclaration.Name:
+		return capabilities.DotaPlayerPeers(ctx, playerId, filters, count, sortBy, minGames)
+	case capabilities.DotaPlayerRecordsDeclaration.Name:
+		return capabilities.DotaPlayerRecords(ctx, playerId, filters)
+	default:
+		return capabilities.DotaComparePlayers(ctx, capabilities.StringsArg(call.Args, "players"), filters)
 	}
 }
 
@@ -239,15 +310,3 @@ func isMentionedOrReplied(user *models.User, update *models.Update) bool {
 
 	return false
 }
-
-func isUnintelligiblePerson(user *models.User, update *models.Update) bool {
-	unintelligibleIds := viper.GetIntSlice("bot.unintelligible_ids")
-
-	for _, id := range unintelligibleIds {
-		if int64(id) == update.Message.From.ID {
-			return true
-		}
-	}
-
-	return false
-}
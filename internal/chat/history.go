@@ -0,0 +1,46 @@
+package chat
+
+import (
+	"context"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/victormamede/benebott/internal/i18n"
+)
+
+// HistoryCommand shows whether the chat history is searchable, admins switch
+// it with "/history on" and "/history off", which deletes the stored messages.
+func HistoryCommand(ctx context.Context, b *bot.Bot, update *models.Update, s *Services) {
+	message := update.Message
+	args := commandArgs(message.Text)
+
+	if args == "" {
+		key := "history.off"
+		if s.History.Enabled(message.Chat.ID) {
+			key = "history.on"
+		}
+		reply(ctx, b, message, s.T(message.Chat.ID, key))
+		return
+	}
+
+	if !isAdmin(ctx, b, message) {
+		reply(ctx, b, message, s.T(message.Chat.ID, "admin.only"))
+		return
+	}
+
+	var err error
+	switch args {
+	case "on":
+		err = s.History.Enable(message.Chat)
+	case "off":
+		err = s.History.Disable(message.Chat.ID)
+	default:
+		err = i18n.Errorf("error.unknown_option", args, "on, off")
+	}
+
+	if err != nil {
+		replyCommandError(ctx, b, message, s, err)
+		return
+	}
+	reply(ctx, b, message, s.T(message.Chat.ID, "history."+args))
+}
@@ -0,0 +1,36 @@
+package chat
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// LanguageCommand shows the language of the chat, admins change it with
+// "/language en". The conversation restarts so the new language is used.
+func LanguageCommand(ctx context.Context, b *bot.Bot, update *models.Update, s *Services) {
+	message := update.Message
+	args := commandArgs(message.Text)
+
+	if args == "" {
+		reply(ctx, b, message, s.T(message.Chat.ID, "language.current",
+			s.Languages.Locale(message.Chat.ID), strings.Join(s.Languages.Catalog().Locales(), ", ")))
+		return
+	}
+
+	if !isAdmin(ctx, b, message) {
+		reply(ctx, b, message, s.T(message.Chat.ID, "admin.only"))
+		return
+	}
+
+	err := s.Languages.Set(message.Chat.ID, args)
+	if err != nil {
+		replyCommandError(ctx, b, message, s, err)
+		return
+	}
+
+	s.Chats.Reset(message.Chat.ID)
+	reply(ctx, b, message, s.T(message.Chat.ID, "language.changed"))
+}
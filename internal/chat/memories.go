@@ -0,0 +1,63 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// MemoriesCommand shows what is remembered about the sender in the chat.
+// "/memories delete <id>" and "/memories clear" delete their facts.
+func MemoriesCommand(ctx context.Context, b *bot.Bot, update *models.Update, s *Services) {
+	message := update.Message
+	if message.From == nil {
+		return
+	}
+	chatID, userID := message.Chat.ID, message.From.ID
+	action, args, _ := strings.Cut(commandArgs(message.Text), " ")
+
+	switch action {
+	case "":
+		facts := s.Memory.Recall(chatID, userID)
+		if len(facts) == 0 {
+			reply(ctx, b, message, s.T(chatID, "memories.empty"))
+			return
+		}
+
+		lines := []string{s.T(chatID, "memories.title")}
+		for _, fact := range facts {
+			lines = append(lines, fmt.Sprintf("#%d %s (%s)", fact.ID, fact.Text, fact.CreatedAt.In(s.Location).Format("02/01/2006")))
+		}
+		reply(ctx, b, message, strings.Join(lines, "\n"))
+	case "delete":
+		factID, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(args), "#"))
+		if err != nil {
+			reply(ctx, b, message, s.T(chatID, "memories.usage"))
+			return
+		}
+
+		found, err := s.Memory.Forget(chatID, userID, factID)
+		if err != nil {
+			replyError(ctx, b, message, s, err)
+			return
+		}
+		if !found {
+			reply(ctx, b, message, s.T(chatID, "memories.not_found", factID))
+			return
+		}
+		reply(ctx, b, message, s.T(chatID, "memories.deleted", factID))
+	case "clear":
+		count, err := s.Memory.ForgetAll(chatID, userID)
+		if err != nil {
+			replyError(ctx, b, message, s, err)
+			return
+		}
+		reply(ctx, b, message, s.T(chatID, "memories.cleared", count))
+	default:
+		reply(ctx, b, message, s.T(chatID, "memories.usage"))
+	}
+}
@@ -0,0 +1,178 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/spf13/viper"
+	"github.com/victormamede/benebott/internal/i18n"
+	"github.com/victormamede/benebott/internal/storage"
+	"google.golang.org/genai"
+)
+
+type participationScore struct {
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+// Participation decides when Benebott joins a conversation without being
+// called. Chats opt in with /participation, persisted in store, and
+// participation.enabled is the default for the others.
+type Participation struct {
+	store    *storage.Store[map[int64]bool]
+	location *time.Location
+
+	mu      sync.Mutex
+	day     string
+	replies map[int64]int
+}
+
+func NewParticipation(store *storage.Store[map[int64]bool], location *time.Location) *Participation {
+	return &Participation{store: store, location: location, replies: map[int64]int{}}
+}
+
+func (p *Participation) Enabled(chatID int64) bool {
+	enabled, ok := false, false
+	p.store.View(func(chats map[int64]bool) {
+		enabled, ok = chats[chatID]
+	})
+	if !ok {
+		return viper.GetBool("participation.enabled")
+	}
+
+	return enabled
+}
+
+func (p *Participation) SetEnabled(chatID int64, enabled bool) error {
+	return p.store.Update(func(chats *map[int64]bool) {
+		(*chats)[chatID] = enabled
+	})
+}
+
+// Replies returns how many times Benebott joined the chat today.
+func (p *Participation) Replies(chatID int64) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.rollDay()
+	return p.replies[chatID]
+}
+
+// rollDay resets the daily counts when the day changes, p.mu must be held.
+func (p *Participation) rollDay() {
+	today := time.Now().In(p.location).Format(time.DateOnly)
+	if p.day != today {
+		p.day = today
+		p.replies = map[int64]int{}
+	}
+}
+
+// ShouldReply scores the message with the classifier model and reports
+// whether it is worth joining in, counting the reply against the daily cap.
+func (p *Participation) ShouldReply(ctx context.Context, message *models.Message, s *Services) (bool, error) {
+	if message.Text == "" || !p.Enabled(message.Chat.ID) {
+		return false, nil
+	}
+	if p.Replies(message.Chat.ID) >= viper.GetInt("participation.daily_cap") {
+		return false, nil
+	}
+
+	score, err := p.score(ctx, message, s)
+	if err != nil {
+		return false, err
+	}
+	if score.Score < viper.GetFloat64("participation.threshold") {
+		return false, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.rollDay()
+	if p.replies[message.Chat.ID] >= viper.GetInt("participation.daily_cap") {
+		return false, nil
+	}
+	p.replies[message.Chat.ID]++
+
+	return true, nil
+}
+
+func (p *Participation) score(ctx context.Context, message *models.Message, s *Services) (participationScore, error) {
+	config := &genai.GenerateContentConfig{
+		ResponseMIMEType: "application/json",
+		ResponseSchema: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"score":  {Type: genai.TypeNumber, Description: "From 0 to 1, how relevant and welcome a reply from Benebott would be"},
+				"reason": {Type: genai.TypeString, Description: "Short justification of the score"},
+			},
+			Required: []string{"score"},
+		},
+	}
+
+	lines := []string{}
+	for _, recent := range s.Recent.Before(message.Chat.ID, message.ID+1, viper.GetInt("participation.context")) {
+		lines = append(lines, recent.String())
+	}
+
+	history := []*genai.Content{
+		genai.NewContentFromText(fmt.Sprintf("Benebott is a bot in this group chat, described as: %q. "+
+			"Nobody called it. Score whether it has something relevant, useful or funny to add to the conversation right now. "+
+			"Most of the time it should stay quiet.", s.renderPrompt(s.Settings.Get(message.Chat.ID).Prompt, message.Chat)), genai.RoleUser),
+		genai.NewContentFromText("Conversation:\n"+strings.Join(lines, "\n"), genai.RoleUser),
+	}
+
+	resp, err := s.AI.Models.GenerateContent(ctx, viper.GetString("participation.model"), history, config)
+	if err != nil {
+		return participationScore{}, err
+	}
+
+	score := participationScore{}
+	err = json.Unmarshal([]byte(resp.Text()), &score)
+
+	return score, err
+}
+
+// ParticipationCommand shows whether Benebott joins conversations on its own
+// in the chat, admins switch it with "/participation on" and "off".
+func ParticipationCommand(ctx context.Context, b *bot.Bot, update *models.Update, s *Services) {
+	message := update.Message
+	args := commandArgs(message.Text)
+
+	if args == "" {
+		status := s.T(message.Chat.ID, "participation.off")
+		if s.Participation.Enabled(message.Chat.ID) {
+			status = s.T(message.Chat.ID, "participation.on")
+		}
+		reply(ctx, b, message, s.T(message.Chat.ID, "participation.status",
+			status, s.Participation.Replies(message.Chat.ID), viper.GetInt("participation.daily_cap")))
+		return
+	}
+
+	if !isAdmin(ctx, b, message) {
+		reply(ctx, b, message, s.T(message.Chat.ID, "admin.only"))
+		return
+	}
+
+	var err error
+	switch args {
+	case "on":
+		err = s.Participation.SetEnabled(message.Chat.ID, true)
+	case "off":
+		err = s.Participation.SetEnabled(message.Chat.ID, false)
+	default:
+		err = i18n.Errorf("error.unknown_option", args, "on, off")
+	}
+
+	if err != nil {
+		replyCommandError(ctx, b, message, s, err)
+		return
+	}
+	reply(ctx, b, message, s.T(message.Chat.ID, "participation.changed", args))
+}
@@ -0,0 +1,166 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/spf13/viper"
+	"github.com/victormamede/benebott/internal/capabilities"
+	"github.com/victormamede/benebott/internal/i18n"
+	"github.com/victormamede/benebott/internal/storage"
+)
+
+// Persona is a character Benebott can play, defined under personas.<id> in
+// the config. Empty fields keep the bot.* defaults.
+type Persona struct {
+	ID          string   `mapstructure:"-"`
+	Name        string   `mapstructure:"name"`
+	Prompt      string   `mapstructure:"prompt"`
+	Style       string   `mapstructure:"style"`
+	Model       string   `mapstructure:"model"`
+	Temperature *float32 `mapstructure:"temperature"`
+	Tools       []string `mapstructure:"tools"`
+}
+
+// instructions returns the persona prompt, or base when it has none,
+// followed by its reply style.
+func (p Persona) instructions(base string) string {
+	prompt := p.Prompt
+	if prompt == "" {
+		prompt = base
+	}
+
+	return p.styled(prompt)
+}
+
+// styled returns prompt followed by the persona reply style.
+func (p Persona) styled(prompt string) string {
+	return strings.TrimSpace(prompt + "\n\n" + p.Style)
+}
+
+// Personas is the registry of the configured personas and the one active in
+// every chat, persisted in store.
+type Personas struct {
+	registry map[string]Persona
+	fallback string
+	store    *storage.Store[map[int64]string]
+}
+
+// LoadPersonas reads the personas of the config, failing on unknown tools.
+// bot.persona is the default one, added without overrides when it isn't
+// configured.
+func LoadPersonas(store *storage.Store[map[int64]string]) (*Personas, error) {
+	registry := map[string]Persona{}
+	err := viper.UnmarshalKey("personas", &registry)
+	if err != nil {
+		return nil, err
+	}
+
+	for id, persona := range registry {
+		persona.ID = id
+		if persona.Name == "" {
+			persona.Name = id
+		}
+		for _, tool := range persona.Tools {
+			if !slices.Contains(capabilities.ToolNames(), tool) {
+				return nil, fmt.Errorf("persona %s: unknown tool %q", id, tool)
+			}
+		}
+		registry[id] = persona
+	}
+
+	fallback := viper.GetString("bot.persona")
+	if _, ok := registry[fallback]; !ok {
+		registry[fallback] = Persona{ID: fallback, Name: fallback}
+	}
+
+	return &Personas{registry: registry, fallback: fallback, store: store}, nil
+}
+
+// IDs returns the persona ids, sorted.
+func (p *Personas) IDs() []string {
+	ids := []string{}
+	for id := range p.registry {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	return ids
+}
+
+func (p *Personas) Get(id string) (Persona, bool) {
+	persona, ok := p.registry[id]
+	return persona, ok
+}
+
+// Active returns the persona of the chat, the default one unless switched.
+func (p *Personas) Active(chatID int64) Persona {
+	id := p.fallback
+	p.store.View(func(chats map[int64]string) {
+		if chosen, ok := chats[chatID]; ok {
+			id = chosen
+		}
+	})
+
+	persona, ok := p.registry[id]
+	if !ok {
+		// The persona was removed from the config
+		return p.registry[p.fallback]
+	}
+
+	return persona
+}
+
+func (p *Personas) Set(chatID int64, id string) error {
+	if _, ok := p.registry[id]; !ok {
+		return i18n.Errorf("persona.unknown", id, strings.Join(p.IDs(), ", "))
+	}
+
+	return p.store.Update(func(chats *map[int64]string) {
+		if id == p.fallback {
+			delete(*chats, chatID)
+			return
+		}
+		(*chats)[chatID] = id
+	})
+}
+
+// PersonaCommand lists the personas, admins switch the one of the chat with
+// "/persona <id>". Switching starts a fresh conversation.
+func PersonaCommand(ctx context.Context, b *bot.Bot, update *models.Update, s *Services) {
+	message := update.Message
+	args := commandArgs(message.Text)
+
+	if args == "" {
+		active := s.Personas.Active(message.Chat.ID)
+		lines := []string{s.T(message.Chat.ID, "persona.title")}
+		for _, id := range s.Personas.IDs() {
+			persona, _ := s.Personas.Get(id)
+			marker := "  "
+			if id == active.ID {
+				marker = "▶ "
+			}
+			lines = append(lines, fmt.Sprintf("%s%s: %s", marker, id, persona.Name))
+		}
+		reply(ctx, b, message, strings.Join(lines, "\n"))
+		return
+	}
+
+	if !isAdmin(ctx, b, message) {
+		reply(ctx, b, message, s.T(message.Chat.ID, "admin.only"))
+		return
+	}
+
+	err := s.Personas.Set(message.Chat.ID, args)
+	if err != nil {
+		replyCommandError(ctx, b, message, s, err)
+		return
+	}
+
+	s.Chats.Reset(message.Chat.ID)
+	reply(ctx, b, message, s.T(message.Chat.ID, "persona.changed", s.Personas.Active(message.Chat.ID).Name))
+}
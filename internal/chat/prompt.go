@@ -0,0 +1,85 @@
+package chat
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/spf13/viper"
+)
+
+// PromptMember is someone recently seen in the chat.
+type PromptMember struct {
+	ID          int64
+	Name        string
+	Username    string
+	DotaAccount string
+}
+
+// PromptData is what the system prompt template can use, e.g.
+// "It is {{.Now.Format "15:04"}} in {{.ChatTitle}}".
+type PromptData struct {
+	ChatTitle string
+	ChatType  string
+	Now       time.Time
+	TimeZone  string
+	Members   []PromptMember
+	Persona   Persona
+	Language  string
+}
+
+// promptData gathers the data of the chat for the prompt template.
+func (s *Services) promptData(chat models.Chat) PromptData {
+	title := chat.Title
+	if title == "" {
+		title = strings.TrimSpace(chat.FirstName + " " + chat.LastName)
+	}
+
+	members := []PromptMember{}
+	for _, message := range s.Recent.All(chat.ID) {
+		if message.UserID == 0 || slices.ContainsFunc(members, func(m PromptMember) bool { return m.ID == message.UserID }) {
+			continue
+		}
+
+		members = append(members, PromptMember{
+			ID:          message.UserID,
+			Name:        message.Name,
+			Username:    message.Username,
+			DotaAccount: viper.GetString(fmt.Sprintf("dota.accounts.%d", message.UserID)),
+		})
+	}
+
+	now := time.Now().In(s.Location)
+	return PromptData{
+		ChatTitle: title,
+		ChatType:  string(chat.Type),
+		Now:       now,
+		TimeZone:  now.Location().String(),
+		Members:   members,
+		Persona:   s.Personas.Active(chat.ID),
+		Language:  s.T(chat.ID, "language.name"),
+	}
+}
+
+// renderPrompt executes prompt as a template with the data of the chat. A
+// broken template is logged and sent as is.
+func (s *Services) renderPrompt(prompt string, chat models.Chat) string {
+	tmpl, err := template.New("prompt").Parse(prompt)
+	if err != nil {
+		log.Println("Prompt template error", err)
+		return prompt
+	}
+
+	text := strings.Builder{}
+	err = tmpl.Execute(&text, s.promptData(chat))
+	if err != nil {
+		log.Println("Prompt template error", err)
+		return prompt
+	}
+
+	return strings.TrimSpace(text.String())
+}
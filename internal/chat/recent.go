@@ -0,0 +1,90 @@
+package chat
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+)
+
+type RecentMessage struct {
+	ID       int
+	UserID   int64
+	Name     string
+	Username string
+	Text     string
+	Date     time.Time
+}
+
+func (m RecentMessage) String() string {
+	return fmt.Sprintf("[%s] %s", m.Name, m.Text)
+}
+
+// RecentMessages keeps the last text messages of every chat in memory, giving
+// context to the features that look at a single message.
+type RecentMessages struct {
+	size int
+
+	mu    sync.Mutex
+	chats map[int64][]RecentMessage
+}
+
+func NewRecentMessages(size int) *RecentMessages {
+	return &RecentMessages{size: size, chats: map[int64][]RecentMessage{}}
+}
+
+func (r *RecentMessages) Add(message *models.Message) {
+	if message.Text == "" {
+		return
+	}
+
+	recent := RecentMessage{
+		ID:   message.ID,
+		Name: "anonymous",
+		Text: message.Text,
+		Date: time.Unix(int64(message.Date), 0),
+	}
+	if message.From != nil {
+		recent.UserID = message.From.ID
+		recent.Name = message.From.FirstName
+		recent.Username = message.From.Username
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	messages := append(r.chats[message.Chat.ID], recent)
+	if len(messages) > r.size {
+		messages = messages[len(messages)-r.size:]
+	}
+	r.chats[message.Chat.ID] = messages
+}
+
+// Before returns up to count messages of the chat sent before messageID,
+// oldest first.
+func (r *RecentMessages) Before(chatID int64, messageID int, count int) []RecentMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	before := []RecentMessage{}
+	for _, message := range r.chats[chatID] {
+		if message.ID < messageID {
+			before = append(before, message)
+		}
+	}
+	if len(before) > count {
+		before = before[len(before)-count:]
+	}
+
+	return before
+}
+
+// All returns the messages kept for the chat, oldest first.
+func (r *RecentMessages) All(chatID int64) []RecentMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.chats[chatID])
+}
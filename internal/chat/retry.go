@@ -0,0 +1,81 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/spf13/viper"
+	"google.golang.org/genai"
+)
+
+// generationModels returns bot.model followed by bot.fallback_models.
+func generationModels() []string {
+	return append([]string{viper.GetString("bot.model")}, viper.GetStringSlice("bot.fallback_models")...)
+}
+
+// retryable reports whether err is transient, so the request may succeed if
+// sent again or to another model.
+func retryable(err error) bool {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// generate sends contents to each model in order until one answers, retrying
+// transient errors. It returns the model that answered.
+func generate(ctx context.Context, client *genai.Client, modelList []string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, string, error) {
+	var err error
+	for _, model := range modelList {
+		var resp *genai.GenerateContentResponse
+		resp, err = generateWithRetry(ctx, client, model, contents, config)
+		if err == nil {
+			return resp, model, nil
+		}
+		if !retryable(err) || ctx.Err() != nil {
+			return nil, model, err
+		}
+
+		log.Println("Model", model, "failed, trying the next one:", err)
+	}
+
+	return nil, "", err
+}
+
+// generateWithRetry retries transient errors up to bot.retry.attempts times,
+// waiting an exponential backoff with jitter between attempts.
+func generateWithRetry(ctx context.Context, client *genai.Client, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	attempts := max(1, viper.GetInt("bot.retry.attempts"))
+	backoff := viper.GetDuration("bot.retry.backoff")
+
+	for attempt := 1; ; attempt++ {
+		resp, err := client.Models.GenerateContent(ctx, model, contents, config)
+		if err == nil || !retryable(err) || attempt >= attempts {
+			return resp, err
+		}
+
+		// Full jitter keeps the chats that failed together from retrying together
+		delay := backoff << (attempt - 1)
+		delay = delay/2 + rand.N(delay/2+1)
+		log.Println("Model", model, "attempt", attempt, "failed, retrying in", delay, ":", err)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/spf13/viper"
+	"github.com/victormamede/benebott/internal/capabilities"
+	"github.com/victormamede/benebott/internal/i18n"
+	"github.com/victormamede/benebott/internal/storage"
+)
+
+// ChatSettings overrides the model settings of one chat, unset fields use
+// the bot.* config.
+type ChatSettings struct {
+	Persona        string   `json:"-"`
+	Prompt         string   `json:",omitempty"`
+	Model          string   `json:",omitempty"`
+	Temperature    *float32 `json:",omitempty"`
+	ThinkingBudget *int32   `json:",omitempty"`
+	Tools          []string `json:",omitempty"`
+}
+
+// Settings keeps the ChatSettings of every chat, persisting them in store.
+type Settings struct {
+	store    *storage.Store[map[int64]*ChatSettings]
+	personas *Personas
+}
+
+func NewSettings(store *storage.Store[map[int64]*ChatSettings], personas *Personas) *Settings {
+	return &Settings{store: store, personas: personas}
+}
+
+// Get returns the effective settings of the chat: the global config, then
+// the active persona, then the overrides of the chat.
+func (s *Settings) Get(chatID int64) ChatSettings {
+	settings := ChatSettings{
+		Prompt: viper.GetString("bot.prompt"),
+		Model:  viper.GetString("bot.model"),
+		Tools:  viper.GetStringSlice("bot.tools"),
+	}
+	if viper.IsSet("bot.temperature") {
+		temperature := float32(viper.GetFloat64("bot.temperature"))
+		settings.Temperature = &temperature
+	}
+	if viper.IsSet("bot.thinking_budget") {
+		budget := viper.GetInt32("bot.thinking_budget")
+		settings.ThinkingBudget = &budget
+	}
+
+	persona := s.personas.Active(chatID)
+	settings.Persona = persona.ID
+	settings.Prompt = persona.instructions(settings.Prompt)
+	if persona.Model != "" {
+		settings.Model = persona.Model
+	}
+	if persona.Temperature != nil {
+		settings.Temperature = persona.Temperature
+	}
+	if len(persona.Tools) > 0 {
+		settings.Tools = slices.Clone(persona.Tools)
+	}
+
+	s.store.View(func(chats map[int64]*ChatSettings) {
+		override, ok := chats[chatID]
+		if !ok {
+			return
+		}
+
+		if override.Prompt != "" {
+			// The override replaces the persona prompt, not its reply style
+			settings.Prompt = persona.styled(override.Prompt)
+		}
+		if override.Model != "" {
+			settings.Model = override.Model
+		}
+		if override.Temperature != nil {
+			settings.Temperature = override.Temperature
+		}
+		if override.ThinkingBudget != nil {
+			settings.ThinkingBudget = override.ThinkingBudget
+		}
+		if len(override.Tools) > 0 {
+			settings.Tools = slices.Clone(override.Tools)
+		}
+	})
+
+	return settings
+}
+
+// Models returns the model of the chat followed by bot.fallback_models.
+func (s *Settings) Models(chatID int64) []string {
+	models := []string{s.Get(chatID).Model}
+	for _, model := range generationModels()[1:] {
+		if model != models[0] {
+			models = append(models, model)
+		}
+	}
+
+	return models
+}
+
+// Set parses value into the setting called name for the chat. An empty
+// value drops the override.
+func (s *Settings) Set(chatID int64, name string, value string) error {
+	var apply func(override *ChatSettings)
+
+	switch name {
+	case "prompt":
+		apply = func(override *ChatSettings) { override.Prompt = value }
+	case "model":
+		apply = func(override *ChatSettings) { override.Model = value }
+	case "temperature":
+		var temperature *float32
+		if value != "" {
+			parsed, err := strconv.ParseFloat(value, 32)
+			if err != nil || parsed < 0 || parsed > 2 {
+				return i18n.Errorf("settings.temperature")
+			}
+			t := float32(parsed)
+			temperature = &t
+		}
+		apply = func(override *ChatSettings) { override.Temperature = temperature }
+	case "thinking":
+		var budget *int32
+		if value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 32)
+			if err != nil || parsed < -1 {
+				return i18n.Errorf("settings.thinking")
+			}
+			b := int32(parsed)
+			budget = &b
+		}
+		apply = func(override *ChatSettings) { override.ThinkingBudget = budget }
+	case "tools":
+		var tools []string
+		if value != "" && value != "all" {
+			for _, tool := range strings.Split(value, ",") {
+				tool = strings.TrimSpace(tool)
+				if !slices.Contains(capabilities.ToolNames(), tool) {
+					return i18n.Errorf("settings.unknown_tool", tool)
+				}
+				tools = append(tools, tool)
+			}
+		}
+		apply = func(override *ChatSettings) { override.Tools = tools }
+	default:
+		return i18n.Errorf("error.unknown_option", name, "prompt, model, temperature, thinking, tools")
+	}
+
+	// Merged in a single update so concurrent changes don't drop each other
+	return s.store.Update(func(chats *map[int64]*ChatSettings) {
+		override, ok := (*chats)[chatID]
+		if !ok {
+			override = &ChatSettings{}
+			(*chats)[chatID] = override
+		}
+		apply(override)
+	})
+}
+
+// Reset drops every override of the chat.
+func (s *Settings) Reset(chatID int64) error {
+	return s.store.Update(func(chats *map[int64]*ChatSettings) {
+		delete(*chats, chatID)
+	})
+}
+
+func (c ChatSettings) String() string {
+	temperature, thinking, tools := "default", "default", "all"
+	if c.Temperature != nil {
+		temperature = strconv.FormatFloat(float64(*c.Temperature), 'g', -1, 32)
+	}
+	if c.ThinkingBudget != nil {
+		thinking = strconv.Itoa(int(*c.ThinkingBudget))
+	}
+	if len(c.Tools) > 0 {
+		tools = strings.Join(c.Tools, ", ")
+	}
+
+	return fmt.Sprintf("persona: %s\nmodel: %s\ntemperature: %s\nthinking: %s\ntools: %s\nprompt: %s",
+		c.Persona, c.Model, temperature, thinking, tools, c.Prompt)
+}
+
+// SettingsCommand shows the model settings of the chat. Admins change them
+// with "/settings <prompt|model|temperature|thinking|tools> [value]", an
+// empty value goes back to the default, and "/settings reset" drops them all.
+func SettingsCommand(ctx context.Context, b *bot.Bot, update *models.Update, s *Services) {
+	message := update.Message
+	name, value, _ := strings.Cut(commandArgs(message.Text), " ")
+	value = strings.TrimSpace(value)
+
+	if name == "" {
+		reply(ctx, b, message, s.T(message.Chat.ID, "settings.current", s.Settings.Get(message.Chat.ID).String(),
+			strings.Join(capabilities.ToolNames(), ", ")))
+		return
+	}
+
+	if !isAdmin(ctx, b, message) {
+		reply(ctx, b, message, s.T(message.Chat.ID, "admin.only"))
+		return
+	}
+
+	var err error
+	if name == "reset" {
+		err = s.Settings.Reset(message.Chat.ID)
+	} else {
+		err = s.Settings.Set(message.Chat.ID, name, value)
+	}
+	if err != nil {
+		replyCommandError(ctx, b, message, s, err)
+		return
+	}
+
+	// The conversation keeps the config it started with
+	s.Chats.Reset(message.Chat.ID)
+	reply(ctx, b, message, s.T(message.Chat.ID, "settings.changed", s.Settings.Get(message.Chat.ID).String()))
+}
@@ -0,0 +1,387 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/spf13/viper"
+	"github.com/victormamede/benebott/internal/i18n"
+	"github.com/victormamede/benebott/internal/storage"
+	"google.golang.org/genai"
+)
+
+const (
+	ToneFix       = "fix"
+	ToneTranslate = "translate"
+	ToneFormalize = "formalize"
+)
+
+var translatorTones []string = []string{ToneFix, ToneTranslate, ToneFormalize}
+
+// TranslatorRule is how the messages of one user are rewritten in one chat.
+type TranslatorRule struct {
+	Tone string
+	// Language the message is translated to, or the formal version written in
+	Language  string
+	MinLength int
+	Cooldown  time.Duration
+	// SampleRate is the chance a matching message is rewritten, 1 means always
+	SampleRate float64
+}
+
+func (r *TranslatorRule) String() string {
+	text := r.Tone
+	if r.Language != "" {
+		text += " " + r.Language
+	}
+
+	return fmt.Sprintf("%s (min=%d cooldown=%s rate=%g)", text, r.MinLength, r.Cooldown, r.SampleRate)
+}
+
+// prompt returns the instruction sent along with the message, in the chat
+// locale. The fix instruction can be replaced with bot.unintelligible_prompt.
+func (r *TranslatorRule) prompt(languages *i18n.Languages, chatID int64) string {
+	language := r.Language
+	if language == "" {
+		language = languages.T(chatID, "language.name")
+	}
+
+	switch r.Tone {
+	case ToneTranslate, ToneFormalize:
+		return languages.T(chatID, "translator."+r.Tone+".instruction", language)
+	}
+
+	if prompt := viper.GetString("bot.unintelligible_prompt"); prompt != "" {
+		return prompt
+	}
+	return languages.T(chatID, "translator.fix.instruction")
+}
+
+// Translator holds the rules of every chat, persisting them in store.
+// The ids in bot.unintelligible_ids get a fix rule in chats without one.
+type Translator struct {
+	store *storage.Store[map[int64]map[int64]*TranslatorRule]
+
+	mu       sync.Mutex
+	lastUsed map[[2]int64]time.Time
+}
+
+func NewTranslator(store *storage.Store[map[int64]map[int64]*TranslatorRule]) *Translator {
+	return &Translator{store: store, lastUsed: map[[2]int64]time.Time{}}
+}
+
+// ParseTone validates a tone name.
+func ParseTone(name string) (string, error) {
+	if !slices.Contains(translatorTones, name) {
+		return "", i18n.Errorf("translator.unknown_tone", name)
+	}
+
+	return name, nil
+}
+
+// Rule returns the rule for the user in the chat, if any.
+func (t *Translator) Rule(chatID int64, userID int64) (TranslatorRule, bool) {
+	var rule TranslatorRule
+	found := false
+	t.store.View(func(rules map[int64]map[int64]*TranslatorRule) {
+		if r, ok := rules[chatID][userID]; ok {
+			rule, found = *r, true
+		}
+	})
+	if found {
+		return rule, true
+	}
+
+	if slices.Contains(viper.GetIntSlice("bot.unintelligible_ids"), int(userID)) {
+		return TranslatorRule{Tone: ToneFix, SampleRate: 1}, true
+	}
+
+	return TranslatorRule{}, false
+}
+
+// Match returns the rule to apply to the message, taking the minimum length,
+// the sampling rate and the cooldown of the rule into account.
+func (t *Translator) Match(message *models.Message) (TranslatorRule, bool) {
+	if message.From == nil || message.Text == "" {
+		return TranslatorRule{}, false
+	}
+
+	rule, ok := t.Rule(message.Chat.ID, message.From.ID)
+	if !ok {
+		return rule, false
+	}
+	if utf8.RuneCountInString(message.Text) < rule.MinLength {
+		return rule, false
+	}
+	if rand.Float64() >= rule.SampleRate {
+		return rule, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := [2]int64{message.Chat.ID, message.From.ID}
+	if time.Since(t.lastUsed[key]) < rule.Cooldown {
+		return rule, false
+	}
+	t.lastUsed[key] = time.Now()
+
+	return rule, true
+}
+
+func (t *Translator) Set(chatID int64, userID int64, rule TranslatorRule) error {
+	return t.store.Update(func(rules *map[int64]map[int64]*TranslatorRule) {
+		if (*rules)[chatID] == nil {
+			(*rules)[chatID] = map[int64]*TranslatorRule{}
+		}
+		(*rules)[chatID][userID] = &rule
+	})
+}
+
+func (t *Translator) Remove(chatID int64, userID int64) error {
+	return t.store.Update(func(rules *map[int64]map[int64]*TranslatorRule) {
+		delete((*rules)[chatID], userID)
+		if len((*rules)[chatID]) == 0 {
+			delete(*rules, chatID)
+		}
+	})
+}
+
+// List returns a copy of the rules of the chat keyed by user id.
+func (t *Translator) List(chatID int64) map[int64]TranslatorRule {
+	list := map[int64]TranslatorRule{}
+	t.store.View(func(rules map[int64]map[int64]*TranslatorRule) {
+		for userID, rule := range rules[chatID] {
+			list[userID] = *rule
+		}
+	})
+
+	return list
+}
+
+type IntelligibleResponse struct {
+	IsCorrect        bool   `json:"isCorrect"`
+	CorrectedVersion string `json:"correctedVersion"`
+	NewTerms         []struct {
+		Term    string `json:"term"`
+		Meaning string `json:"meaning"`
+	} `json:"newTerms"`
+}
+
+func translateUnintelligible(ctx context.Context, b *bot.Bot, update *models.Update, s *Services, rule TranslatorRule) {
+	config := &genai.GenerateContentConfig{
+		ResponseMIMEType: "application/json",
+		ResponseSchema: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"isCorrect": {Type: genai.TypeBoolean, Description: "Wether the message is already correct. (true means correct)"},
+				"correctedVersion": {
+					Description: "The rewritten form of the message",
+					Type:        genai.TypeString,
+				},
+				"newTerms": {
+					Description: "Slang, nicknames and names used in the message or the recent messages that are not typos and are not in the glossary yet",
+					Type:        genai.TypeArray,
+					Items: &genai.Schema{
+						Type: genai.TypeObject,
+						Properties: map[string]*genai.Schema{
+							"term":    {Type: genai.TypeString},
+							"meaning": {Type: genai.TypeString, Description: "What the term means or who it refers to"},
+						},
+						Required: []string{"term", "meaning"},
+					},
+				},
+			},
+			Required: []string{"isCorrect"},
+		},
+	}
+
+	chatID := update.Message.Chat.ID
+	history := []*genai.Content{genai.NewContentFromText(rule.prompt(s.Languages, chatID), genai.RoleUser)}
+
+	// Slang and references to earlier messages are not mistakes
+	if entries := s.Glossary.Entries(chatID); len(entries) > 0 {
+		history = append(history, genai.NewContentFromText(
+			s.T(chatID, "translator.glossary", strings.Join(entries, "\n")), genai.RoleUser))
+	}
+
+	recent := s.Recent.Before(chatID, update.Message.ID, viper.GetInt("bot.translator_context"))
+	if len(recent) > 0 {
+		lines := []string{}
+		for _, message := range recent {
+			lines = append(lines, message.String())
+		}
+		history = append(history, genai.NewContentFromText(
+			s.T(chatID, "translator.context", strings.Join(lines, "\n")), genai.RoleUser))
+	}
+
+	history = append(history, genai.NewContentFromText(s.T(chatID, "translator.message", update.Message.Text), genai.RoleUser))
+
+	resp, model, err := generate(ctx, s.AI, s.Settings.Models(chatID), history, config)
+
+	if err != nil {
+		replyError(ctx, b, update.Message, s, err)
+		return
+	}
+	log.Println("Translation in chat", chatID, "answered by", model)
+
+	for _, cand := range resp.Candidates {
+		if cand.Content != nil {
+			for _, part := range cand.Content.Parts {
+
+				response := IntelligibleResponse{}
+				err = json.Unmarshal([]byte(part.Text), &response)
+				if err != nil {
+					replyError(ctx, b, update.Message, s, fmt.Errorf("decoding correction: %w", err))
+					return
+				}
+
+				learnGlossaryTerms(s, chatID, response)
+
+				if response.IsCorrect || response.CorrectedVersion == "" {
+					return
+				}
+
+				reply(ctx, b, update.Message, fmt.Sprintf("%s: \"%s\"", s.T(chatID, "translator."+rule.Tone+".prefix"), response.CorrectedVersion))
+			}
+		}
+	}
+}
+
+// learnGlossaryTerms adds the new terms the translator found in the chat to
+// its glossary.
+func learnGlossaryTerms(s *Services, chatID int64, response IntelligibleResponse) {
+	if len(response.NewTerms) == 0 {
+		return
+	}
+
+	learned := map[string]string{}
+	for _, term := range response.NewTerms {
+		learned[term.Term] = term.Meaning
+	}
+
+	added, err := s.Glossary.Learn(chatID, learned)
+	if err != nil {
+		log.Println("Glossary error", err)
+		return
+	}
+	if len(added) > 0 {
+		log.Println("Learned glossary terms in chat", chatID, added)
+	}
+}
+
+// TranslatorCommand manages the translator rules of the chat. Rules are set
+// replying to a message of the user, e.g. "/translator set translate English
+// min=10 cooldown=30m rate=0.5", and dropped with "/translator remove".
+func TranslatorCommand(ctx context.Context, b *bot.Bot, update *models.Update, s *Services) {
+	message := update.Message
+	if !isAdmin(ctx, b, message) {
+		reply(ctx, b, message, s.T(message.Chat.ID, "admin.only"))
+		return
+	}
+
+	fields := strings.Fields(commandArgs(message.Text))
+	if len(fields) == 0 {
+		reply(ctx, b, message, translatorRules(s, message.Chat.ID))
+		return
+	}
+
+	target := message.ReplyToMessage
+	if target == nil || target.From == nil {
+		reply(ctx, b, message, s.T(message.Chat.ID, "translator.usage"))
+		return
+	}
+
+	var err error
+	switch fields[0] {
+	case "set":
+		var rule TranslatorRule
+		rule, err = parseTranslatorRule(fields[1:])
+		if err == nil {
+			err = s.Translator.Set(message.Chat.ID, target.From.ID, rule)
+		}
+		if err == nil {
+			reply(ctx, b, message, s.T(message.Chat.ID, "translator.set", target.From.FirstName, rule.String()))
+		}
+	case "remove":
+		err = s.Translator.Remove(message.Chat.ID, target.From.ID)
+		if err == nil {
+			reply(ctx, b, message, s.T(message.Chat.ID, "translator.removed", target.From.FirstName))
+		}
+	default:
+		err = i18n.Errorf("error.unknown_option", fields[0], "set, remove")
+	}
+
+	if err != nil {
+		replyCommandError(ctx, b, message, s, err)
+	}
+}
+
+func parseTranslatorRule(fields []string) (TranslatorRule, error) {
+	if len(fields) == 0 {
+		return TranslatorRule{}, i18n.Errorf("translator.tone")
+	}
+
+	tone, err := ParseTone(fields[0])
+	if err != nil {
+		return TranslatorRule{}, err
+	}
+
+	rule := TranslatorRule{Tone: tone, SampleRate: 1}
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			rule.Language = strings.TrimSpace(rule.Language + " " + field)
+			continue
+		}
+
+		switch key {
+		case "min":
+			rule.MinLength, err = strconv.Atoi(value)
+		case "cooldown":
+			rule.Cooldown, err = time.ParseDuration(value)
+		case "rate":
+			rule.SampleRate, err = strconv.ParseFloat(value, 64)
+			if err == nil && (rule.SampleRate <= 0 || rule.SampleRate > 1) {
+				return TranslatorRule{}, i18n.Errorf("translator.rate")
+			}
+		default:
+			return TranslatorRule{}, i18n.Errorf("error.unknown_option", key, "min, cooldown, rate")
+		}
+		if err != nil {
+			return TranslatorRule{}, i18n.Errorf("translator.invalid", value, key)
+		}
+	}
+
+	if tone == ToneTranslate && rule.Language == "" {
+		return TranslatorRule{}, i18n.Errorf("translator.language")
+	}
+
+	return rule, nil
+}
+
+func translatorRules(s *Services, chatID int64) string {
+	rules := s.Translator.List(chatID)
+	if len(rules) == 0 {
+		return s.T(chatID, "translator.none")
+	}
+
+	lines := []string{s.T(chatID, "translator.rules")}
+	for userID, rule := range rules {
+		lines = append(lines, fmt.Sprintf("%d: %s", userID, rule.String()))
+	}
+	slices.Sort(lines[1:])
+
+	return strings.Join(lines, "\n")
+}
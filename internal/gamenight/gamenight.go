@@ -0,0 +1,370 @@
+package gamenight
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/victormamede/benebott/internal/i18n"
+	"github.com/victormamede/benebott/internal/storage"
+)
+
+const (
+	maxSlots     = 9
+	tickInterval = 30 * time.Second
+	slotFormat   = "02/01 15:04"
+)
+
+var slotLayouts []string = []string{"15:04", "15h04", "15h", "02/01 15:04", "02/01 15h", "2006-01-02 15:04"}
+
+// GameNight is the state of the scheduling of one chat, from the poll until
+// the reminder is sent.
+type GameNight struct {
+	ChatID      int64
+	PollID      string
+	PollMessage int
+	Slots       []time.Time
+	Votes       map[int64][]int
+	Voters      map[int64]Voter
+	CloseAt     time.Time
+	Chosen      int
+}
+
+type Voter struct {
+	Name     string
+	Username string
+}
+
+// Planner runs the game nights of every chat, persisting them in store.
+type Planner struct {
+	store        *storage.Store[map[int64]*GameNight]
+	location     *time.Location
+	closeBefore  time.Duration
+	remindBefore time.Duration
+	languages    *i18n.Languages
+}
+
+func NewPlanner(store *storage.Store[map[int64]*GameNight], location *time.Location, closeBefore time.Duration, remindBefore time.Duration, languages *i18n.Languages) *Planner {
+	return &Planner{
+		store:        store,
+		location:     location,
+		closeBefore:  closeBefore,
+		remindBefore: remindBefore,
+		languages:    languages,
+	}
+}
+
+// ParseSlots reads times like "21:00" or "25/12 20:30" in the planner time
+// zone. Times without a date are the next occurrence of that hour.
+func (p *Planner) ParseSlots(values []string) ([]time.Time, error) {
+	now := time.Now().In(p.location)
+	slots := []time.Time{}
+
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		slot, err := p.parseSlot(value, now)
+		if err != nil {
+			return nil, err
+		}
+		slots = append(slots, slot)
+	}
+
+	if len(slots) == 0 {
+		return nil, i18n.Errorf("gamenight.no_slots")
+	}
+	if len(slots) > maxSlots {
+		return nil, i18n.Errorf("gamenight.too_many_slots", maxSlots)
+	}
+
+	slices.SortFunc(slots, func(a, b time.Time) int { return a.Compare(b) })
+	return slots, nil
+}
+
+func (p *Planner) parseSlot(value string, now time.Time) (time.Time, error) {
+	for _, layout := range slotLayouts {
+		parsed, err := time.ParseInLocation(layout, value, p.location)
+		if err != nil {
+			continue
+		}
+
+		year := parsed.Year()
+		month, day := parsed.Month(), parsed.Day()
+		if !strings.Contains(layout, "01") {
+			month, day = now.Month(), now.Day()
+		}
+		if !strings.Contains(layout, "2006") {
+			year = now.Year()
+		}
+
+		slot := time.Date(year, month, day, parsed.Hour(), parsed.Minute(), 0, 0, p.location)
+		if slot.Before(now) && !strings.Contains(layout, "01") {
+			slot = slot.AddDate(0, 0, 1)
+		} else if slot.Before(now) && !strings.Contains(layout, "2006") {
+			slot = slot.AddDate(1, 0, 0)
+		}
+
+		return slot, nil
+	}
+
+	return time.Time{}, i18n.Errorf("gamenight.invalid_slot", value)
+}
+
+// Start sends the poll for the given slots, replacing any game night the
+// chat was already planning and stopping its poll if still open.
+func (p *Planner) Start(ctx context.Context, b *bot.Bot, chatID int64, slots []time.Time) error {
+	options := []models.InputPollOption{}
+	for _, slot := range slots {
+		options = append(options, models.InputPollOption{Text: slot.Format(slotFormat)})
+	}
+	options = append(options, models.InputPollOption{Text: p.languages.T(chatID, "gamenight.cant")})
+
+	isAnonymous := false
+	message, err := b.SendPoll(ctx, &bot.SendPollParams{
+		ChatID:                chatID,
+		Question:              p.languages.T(chatID, "gamenight.question"),
+		Options:               options,
+		IsAnonymous:           &isAnonymous,
+		AllowsMultipleAnswers: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	closeAt := slots[0].Add(-p.closeBefore)
+	if closeAt.Before(time.Now()) {
+		closeAt = slots[0]
+	}
+
+	var previous *GameNight
+	err = p.store.Update(func(nights *map[int64]*GameNight) {
+		previous = (*nights)[chatID]
+		(*nights)[chatID] = &GameNight{
+			ChatID:      chatID,
+			PollID:      message.Poll.ID,
+			PollMessage: message.ID,
+			Slots:       slots,
+			Votes:       map[int64][]int{},
+			Voters:      map[int64]Voter{},
+			CloseAt:     closeAt,
+			Chosen:      -1,
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	// Votes on the replaced poll would be ignored
+	if previous != nil && previous.Chosen < 0 {
+		_, err = b.StopPoll(ctx, &bot.StopPollParams{ChatID: chatID, MessageID: previous.PollMessage})
+		if err != nil {
+			log.Println("Stop poll error", err)
+		}
+	}
+
+	return nil
+}
+
+// Answer records a vote on one of the game night polls.
+func (p *Planner) Answer(answer *models.PollAnswer) {
+	if answer.User == nil {
+		return
+	}
+
+	err := p.store.Update(func(nights *map[int64]*GameNight) {
+		for _, night := range *nights {
+			if night.PollID != answer.PollID {
+				continue
+			}
+
+			if len(answer.OptionIDs) == 0 {
+				delete(night.Votes, answer.User.ID)
+				return
+			}
+
+			night.Votes[answer.User.ID] = answer.OptionIDs
+			night.Voters[answer.User.ID] = Voter{Name: answer.User.FirstName, Username: answer.User.Username}
+		}
+	})
+	if err != nil {
+		log.Println("Game night save error", err)
+	}
+}
+
+// Close stops the poll of the chat and announces the most voted slot.
+func (p *Planner) Close(ctx context.Context, b *bot.Bot, chatID int64) error {
+	var night *GameNight
+	err := p.store.Update(func(nights *map[int64]*GameNight) {
+		current, ok := (*nights)[chatID]
+		if !ok || current.Chosen >= 0 {
+			return
+		}
+
+		current.Chosen = current.mostVoted()
+		if current.Chosen < 0 {
+			delete(*nights, chatID)
+		}
+
+		closed := *current
+		night = &closed
+	})
+	if err != nil {
+		return err
+	}
+	if night == nil {
+		return i18n.Errorf("gamenight.no_poll")
+	}
+
+	_, err = b.StopPoll(ctx, &bot.StopPollParams{ChatID: chatID, MessageID: night.PollMessage})
+	if err != nil {
+		log.Println("Stop poll error", err)
+	}
+
+	text := p.languages.T(chatID, "gamenight.nobody")
+	if night.Chosen >= 0 {
+		text = p.languages.T(chatID, "gamenight.scheduled",
+			night.Slots[night.Chosen].Format(slotFormat), night.mentions())
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:          chatID,
+		Text:            text,
+		ParseMode:       models.ParseModeHTML,
+		ReplyParameters: &models.ReplyParameters{MessageID: night.PollMessage},
+	})
+	return err
+}
+
+// Cancel drops the game night of the chat, stopping its poll if still open.
+func (p *Planner) Cancel(ctx context.Context, b *bot.Bot, chatID int64) error {
+	var night *GameNight
+	err := p.store.Update(func(nights *map[int64]*GameNight) {
+		night = (*nights)[chatID]
+		delete(*nights, chatID)
+	})
+	if err != nil {
+		return err
+	}
+	if night == nil {
+		return i18n.Errorf("gamenight.none")
+	}
+
+	if night.Chosen < 0 {
+		_, err = b.StopPoll(ctx, &bot.StopPollParams{ChatID: chatID, MessageID: night.PollMessage})
+	}
+	return err
+}
+
+// Run closes polls and sends reminders when they are due, until ctx is done.
+func (p *Planner) Run(ctx context.Context, b *bot.Bot) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.tick(ctx, b)
+		}
+	}
+}
+
+func (p *Planner) tick(ctx context.Context, b *bot.Bot) {
+	now := time.Now()
+	toClose := []int64{}
+	toRemind := []*GameNight{}
+
+	err := p.store.Update(func(nights *map[int64]*GameNight) {
+		for chatID, night := range *nights {
+			if night.Chosen < 0 {
+				if !now.Before(night.CloseAt) {
+					toClose = append(toClose, chatID)
+				}
+				continue
+			}
+
+			if !now.Before(night.Slots[night.Chosen].Add(-p.remindBefore)) {
+				toRemind = append(toRemind, night)
+				delete(*nights, chatID)
+			}
+		}
+	})
+	if err != nil {
+		log.Println("Game night save error", err)
+	}
+
+	for _, chatID := range toClose {
+		err := p.Close(ctx, b, chatID)
+		if err != nil {
+			log.Println("Game night close error", err)
+		}
+	}
+
+	for _, night := range toRemind {
+		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID:    night.ChatID,
+			Text:      p.languages.T(night.ChatID, "gamenight.reminder", night.Slots[night.Chosen].Format("15:04"), night.mentions()),
+			ParseMode: models.ParseModeHTML,
+		})
+		if err != nil {
+			log.Println("Game night reminder error", err)
+		}
+	}
+}
+
+// mostVoted returns the slot with the most votes, the earliest on ties, or
+// -1 when nobody can make it.
+func (n *GameNight) mostVoted() int {
+	counts := make([]int, len(n.Slots))
+	for _, options := range n.Votes {
+		for _, option := range options {
+			if option < len(counts) {
+				counts[option]++
+			}
+		}
+	}
+
+	best := slices.Max(counts)
+	if best == 0 {
+		return -1
+	}
+
+	return slices.Index(counts, best)
+}
+
+func (n *GameNight) attendees() []int64 {
+	ids := []int64{}
+	for id, options := range n.Votes {
+		if slices.Contains(options, n.Chosen) {
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, func(a, b int64) int { return cmp.Compare(a, b) })
+
+	return ids
+}
+
+func (n *GameNight) mentions() string {
+	mentions := []string{}
+	for _, id := range n.attendees() {
+		voter := n.Voters[id]
+		if voter.Username != "" {
+			mentions = append(mentions, "@"+html.EscapeString(voter.Username))
+			continue
+		}
+		mentions = append(mentions, fmt.Sprintf(`<a href="tg://user?id=%d">%s</a>`, id, html.EscapeString(voter.Name)))
+	}
+
+	return strings.Join(mentions, " ")
+}
@@ -0,0 +1,69 @@
+package history
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/genai"
+)
+
+// Embedder turns texts into vectors whose similarity reflects their meaning.
+type Embedder interface {
+	// EmbedDocuments embeds texts that will be stored and searched.
+	EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error)
+	// EmbedQuery embeds a search query.
+	EmbedQuery(ctx context.Context, text string) ([]float32, error)
+}
+
+// GeminiEmbedder uses the Gemini embeddings API.
+type GeminiEmbedder struct {
+	client     *genai.Client
+	model      string
+	dimensions int32
+}
+
+// NewGeminiEmbedder returns an embedder for model. dimensions reduces the
+// size of the vectors, 0 keeps the model default.
+func NewGeminiEmbedder(client *genai.Client, model string, dimensions int32) *GeminiEmbedder {
+	return &GeminiEmbedder{client: client, model: model, dimensions: dimensions}
+}
+
+func (e *GeminiEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.embed(ctx, texts, "RETRIEVAL_DOCUMENT")
+}
+
+func (e *GeminiEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := e.embed(ctx, []string{text}, "RETRIEVAL_QUERY")
+	if err != nil {
+		return nil, err
+	}
+
+	return vectors[0], nil
+}
+
+func (e *GeminiEmbedder) embed(ctx context.Context, texts []string, task string) ([][]float32, error) {
+	contents := []*genai.Content{}
+	for _, text := range texts {
+		contents = append(contents, genai.NewContentFromText(text, genai.RoleUser))
+	}
+
+	config := &genai.EmbedContentConfig{TaskType: task}
+	if e.dimensions > 0 {
+		config.OutputDimensionality = &e.dimensions
+	}
+
+	resp, err := e.client.Models.EmbedContent(ctx, e.model, contents, config)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("asked for %d embeddings, got %d", len(texts), len(resp.Embeddings))
+	}
+
+	vectors := [][]float32{}
+	for _, embedding := range resp.Embeddings {
+		vectors = append(vectors, embedding.Values)
+	}
+
+	return vectors, nil
+}
@@ -0,0 +1,143 @@
+package history
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// record is an Entry as written to the history file of its chat, one JSON
+// object per line with the vector packed as little endian float32s.
+type record struct {
+	MessageID int
+	Author    string
+	Text      string
+	Date      time.Time
+	Vector    []byte
+}
+
+func chatPath(dir string, chatID int64) string {
+	return filepath.Join(dir, strconv.FormatInt(chatID, 10)+".jsonl")
+}
+
+func pack(vector []float32) []byte {
+	packed := make([]byte, 4*len(vector))
+	for n, value := range vector {
+		binary.LittleEndian.PutUint32(packed[4*n:], math.Float32bits(value))
+	}
+
+	return packed
+}
+
+func unpack(packed []byte) []float32 {
+	vector := make([]float32, len(packed)/4)
+	for n := range vector {
+		vector[n] = math.Float32frombits(binary.LittleEndian.Uint32(packed[4*n:]))
+	}
+
+	return vector
+}
+
+// readEntries loads the history file at path, returning its entries and how
+// many lines it has. Lines that can't be decoded, like one cut by a crash
+// while appending, are skipped.
+func readEntries(path string) ([]Entry, int, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Entry{}, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	entries := []Entry{}
+	lines := 0
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		lines++
+
+		r := record{}
+		err := json.Unmarshal(scanner.Bytes(), &r)
+		if err != nil {
+			log.Println("Skipping history line", lines, "of", path, err)
+			continue
+		}
+
+		entries = append(entries, Entry{
+			MessageID: r.MessageID,
+			Author:    r.Author,
+			Text:      r.Text,
+			Date:      r.Date,
+			Vector:    unpack(r.Vector),
+		})
+	}
+
+	return entries, lines, scanner.Err()
+}
+
+func encodeEntries(entries []Entry) ([]byte, error) {
+	content := []byte{}
+	for _, entry := range entries {
+		line, err := json.Marshal(record{
+			MessageID: entry.MessageID,
+			Author:    entry.Author,
+			Text:      entry.Text,
+			Date:      entry.Date,
+			Vector:    pack(entry.Vector),
+		})
+		if err != nil {
+			return nil, err
+		}
+		content = append(append(content, line...), '\n')
+	}
+
+	return content, nil
+}
+
+// appendEntries adds entries to the end of the history file at path.
+func appendEntries(path string, entries []Entry) error {
+	content, err := encodeEntries(entries)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(content)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// writeEntries replaces the history file at path with entries.
+func writeEntries(path string, entries []Entry) error {
+	content, err := encodeEntries(entries)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves a partial file
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, content, 0o644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,389 @@
+package history
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/victormamede/benebott/internal/storage"
+)
+
+const (
+	flushInterval = time.Minute
+	// Most texts the embeddings API takes in one request
+	maxBatch = 100
+	// Most messages waiting to be embedded per chat, the oldest are dropped
+	maxPending = 10 * maxBatch
+	// How long the last flush may take when shutting down
+	shutdownTimeout = 30 * time.Second
+)
+
+var errNotEnabled = errors.New("chat history search is not enabled in this chat, an admin can enable it with /history on")
+
+// Entry is a stored message with the embedding of its text.
+type Entry struct {
+	MessageID int
+	Author    string
+	Text      string
+	Date      time.Time
+	Vector    []float32
+}
+
+// Chat is a chat that opted in to be searchable.
+type Chat struct {
+	Username string
+}
+
+// Result is a message matching a search.
+type Result struct {
+	Entry
+	Score float64
+	Link  string
+}
+
+// Index keeps the history of the opted in chats. The chats are persisted in
+// store and their messages are appended to one file per chat in dir, so
+// saving new messages never rewrites the whole history. Messages are
+// embedded in batches by Run, so they become searchable about a minute after
+// being sent.
+type Index struct {
+	store       *storage.Store[map[int64]*Chat]
+	dir         string
+	embedder    Embedder
+	maxMessages int
+
+	mu      sync.Mutex
+	pending map[int64][]Entry
+
+	entriesMu sync.Mutex
+	entries   map[int64][]Entry
+	// lines written to the file of each chat, it is compacted past twice
+	// maxMessages
+	lines map[int64]int
+}
+
+// NewIndex loads the history of the chats in store from the files in dir.
+func NewIndex(store *storage.Store[map[int64]*Chat], dir string, embedder Embedder, maxMessages int) (*Index, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	i := &Index{
+		store:       store,
+		dir:         dir,
+		embedder:    embedder,
+		maxMessages: maxMessages,
+		pending:     map[int64][]Entry{},
+		entries:     map[int64][]Entry{},
+		lines:       map[int64]int{},
+	}
+
+	chatIDs := []int64{}
+	store.View(func(chats map[int64]*Chat) {
+		for chatID := range chats {
+			chatIDs = append(chatIDs, chatID)
+		}
+	})
+
+	for _, chatID := range chatIDs {
+		entries, lines, err := readEntries(chatPath(dir, chatID))
+		if err != nil {
+			return nil, fmt.Errorf("history of chat %d: %w", chatID, err)
+		}
+		if len(entries) > maxMessages {
+			entries = entries[len(entries)-maxMessages:]
+		}
+		i.entries[chatID], i.lines[chatID] = entries, lines
+	}
+
+	return i, nil
+}
+
+func (i *Index) Enabled(chatID int64) bool {
+	enabled := false
+	i.store.View(func(chats map[int64]*Chat) {
+		_, enabled = chats[chatID]
+	})
+
+	return enabled
+}
+
+// Enable starts storing the messages of the chat.
+func (i *Index) Enable(chat models.Chat) error {
+	return i.store.Update(func(chats *map[int64]*Chat) {
+		if (*chats)[chat.ID] == nil {
+			(*chats)[chat.ID] = &Chat{Username: chat.Username}
+		}
+	})
+}
+
+// Disable stops storing the messages of the chat and deletes the stored ones.
+func (i *Index) Disable(chatID int64) error {
+	i.mu.Lock()
+	delete(i.pending, chatID)
+	i.mu.Unlock()
+
+	err := i.store.Update(func(chats *map[int64]*Chat) {
+		delete(*chats, chatID)
+	})
+	if err != nil {
+		return err
+	}
+
+	i.entriesMu.Lock()
+	defer i.entriesMu.Unlock()
+
+	delete(i.entries, chatID)
+	delete(i.lines, chatID)
+
+	err = os.Remove(chatPath(i.dir, chatID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Add queues a message of an opted in chat to be embedded. Commands and
+// messages without text are skipped.
+func (i *Index) Add(message *models.Message) {
+	text := strings.TrimSpace(message.Text)
+	if text == "" || strings.HasPrefix(text, "/") || !i.Enabled(message.Chat.ID) {
+		return
+	}
+
+	author := "anonymous"
+	if message.From != nil {
+		author = message.From.FirstName
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.queue(message.Chat.ID, append(i.pending[message.Chat.ID], Entry{
+		MessageID: message.ID,
+		Author:    author,
+		Text:      text,
+		Date:      time.Unix(int64(message.Date), 0),
+	}))
+}
+
+// queue sets the pending messages of the chat, dropping the oldest past
+// maxPending. i.mu must be held.
+func (i *Index) queue(chatID int64, entries []Entry) {
+	if len(entries) > maxPending {
+		log.Println("History queue of chat", chatID, "is full, dropping", len(entries)-maxPending, "messages")
+		entries = entries[len(entries)-maxPending:]
+	}
+
+	i.pending[chatID] = entries
+}
+
+// Run embeds the queued messages periodically, until ctx is done. The queue
+// is flushed one last time before returning.
+func (i *Index) Run(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// ctx can't be used to embed anymore
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+
+			err := i.Flush(shutdownCtx)
+			if err != nil {
+				log.Println("History flush error", err)
+			}
+			return
+		case <-ticker.C:
+			err := i.Flush(ctx)
+			if err != nil {
+				log.Println("History flush error", err)
+			}
+		}
+	}
+}
+
+// Flush embeds and stores the queued messages. Messages that failed are
+// queued again for the next flush, ahead of the newer ones.
+func (i *Index) Flush(ctx context.Context) error {
+	i.mu.Lock()
+	pending := i.pending
+	i.pending = map[int64][]Entry{}
+	i.mu.Unlock()
+
+	var errs []error
+	for chatID, entries := range pending {
+		for start := 0; start < len(entries); start += maxBatch {
+			batch := entries[start:min(start+maxBatch, len(entries))]
+
+			err := i.save(ctx, chatID, batch)
+			if err != nil {
+				errs = append(errs, err)
+
+				i.mu.Lock()
+				i.queue(chatID, append(slices.Clone(batch), i.pending[chatID]...))
+				i.mu.Unlock()
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (i *Index) save(ctx context.Context, chatID int64, batch []Entry) error {
+	texts := []string{}
+	for _, entry := range batch {
+		texts = append(texts, entry.Author+": "+entry.Text)
+	}
+
+	vectors, err := i.embedder.EmbedDocuments(ctx, texts)
+	if err != nil {
+		return err
+	}
+	if len(vectors) != len(batch) {
+		return fmt.Errorf("asked for %d embeddings, got %d", len(batch), len(vectors))
+	}
+
+	embedded := []Entry{}
+	for n, entry := range batch {
+		entry.Vector = normalize(vectors[n])
+		embedded = append(embedded, entry)
+	}
+
+	i.entriesMu.Lock()
+	defer i.entriesMu.Unlock()
+
+	if !i.Enabled(chatID) {
+		// Disabled while the batch was being embedded
+		return nil
+	}
+
+	path := chatPath(i.dir, chatID)
+	err = appendEntries(path, embedded)
+	if err != nil {
+		return err
+	}
+	i.lines[chatID] += len(embedded)
+
+	entries := append(i.entries[chatID], embedded...)
+	if len(entries) > i.maxMessages {
+		entries = entries[len(entries)-i.maxMessages:]
+	}
+	i.entries[chatID] = entries
+
+	if i.lines[chatID] < 2*i.maxMessages {
+		return nil
+	}
+
+	// Drop the lines of the messages past maxMessages
+	err = writeEntries(path, entries)
+	if err != nil {
+		return fmt.Errorf("compacting history of chat %d: %w", chatID, err)
+	}
+	i.lines[chatID] = len(entries)
+
+	return nil
+}
+
+// Search returns the limit messages of the chat closest in meaning to query,
+// best first.
+func (i *Index) Search(ctx context.Context, chatID int64, query string, limit int) ([]Result, error) {
+	// Checked first to spare the embedding call
+	if !i.Enabled(chatID) {
+		return nil, errNotEnabled
+	}
+
+	vector, err := i.embedder.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	vector = normalize(vector)
+
+	i.entriesMu.Lock()
+	defer i.entriesMu.Unlock()
+
+	username, enabled := "", false
+	i.store.View(func(chats map[int64]*Chat) {
+		if chat, ok := chats[chatID]; ok {
+			username, enabled = chat.Username, true
+		}
+	})
+	if !enabled {
+		// Disabled while the query was being embedded
+		return nil, errNotEnabled
+	}
+
+	results := []Result{}
+	for _, entry := range i.entries[chatID] {
+		if len(entry.Vector) != len(vector) {
+			// Embedded with another model or size
+			continue
+		}
+
+		results = append(results, Result{
+			Entry: entry,
+			Score: dot(vector, entry.Vector),
+			Link:  messageLink(chatID, username, entry.MessageID),
+		})
+	}
+
+	slices.SortFunc(results, func(a, b Result) int { return cmp.Compare(b.Score, a.Score) })
+	return results[:min(limit, len(results))], nil
+}
+
+// messageLink returns the t.me link of a message. Only supergroups and
+// channels have them, "" is returned for other chats.
+func messageLink(chatID int64, username string, messageID int) string {
+	if username != "" {
+		return fmt.Sprintf("https://t.me/%s/%d", username, messageID)
+	}
+
+	// Supergroup ids are -100 followed by the id used in private links
+	id := strconv.FormatInt(chatID, 10)
+	if !strings.HasPrefix(id, "-100") {
+		return ""
+	}
+
+	return fmt.Sprintf("https://t.me/c/%s/%d", strings.TrimPrefix(id, "-100"), messageID)
+}
+
+func normalize(vector []float32) []float32 {
+	norm := 0.0
+	for _, value := range vector {
+		norm += float64(value) * float64(value)
+	}
+	if norm == 0 {
+		return vector
+	}
+
+	norm = math.Sqrt(norm)
+	normalized := make([]float32, len(vector))
+	for n, value := range vector {
+		normalized[n] = float32(float64(value) / norm)
+	}
+
+	return normalized
+}
+
+// dot is the cosine similarity of two normalized vectors.
+func dot(a []float32, b []float32) float64 {
+	sum := 0.0
+	for n := range a {
+		sum += float64(a[n]) * float64(b[n])
+	}
+
+	return sum
+}
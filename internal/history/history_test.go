@@ -0,0 +1,275 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-telegram/bot/models"
+	"github.com/victormamede/benebott/internal/storage"
+)
+
+const testChat int64 = -1001234567890
+
+// fakeEmbedder returns the vector configured for each text, or unknown for
+// texts without one.
+type fakeEmbedder struct {
+	vectors map[string][]float32
+	err     error
+}
+
+var unknown = []float32{0, 0, 1}
+
+func (e *fakeEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	vectors := [][]float32{}
+	for _, text := range texts {
+		vectors = append(vectors, e.vector(text))
+	}
+
+	return vectors, nil
+}
+
+func (e *fakeEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	return e.vector(text), nil
+}
+
+func (e *fakeEmbedder) vector(text string) []float32 {
+	if vector, ok := e.vectors[text]; ok {
+		return vector
+	}
+
+	return unknown
+}
+
+func newTestIndex(t *testing.T, dir string, embedder Embedder, maxMessages int) *Index {
+	t.Helper()
+
+	store, err := storage.Open(dir, "history", map[int64]*Chat{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := NewIndex(store, filepath.Join(dir, "history"), embedder, maxMessages)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return index
+}
+
+func textMessage(chatID int64, id int, text string) *models.Message {
+	return &models.Message{
+		ID:   id,
+		Chat: models.Chat{ID: chatID},
+		From: &models.User{FirstName: "Ana"},
+		Text: text,
+	}
+}
+
+func TestAddSkipsCommandsEmptyTextAndOtherChats(t *testing.T) {
+	index := newTestIndex(t, t.TempDir(), &fakeEmbedder{}, 100)
+	err := index.Enable(models.Chat{ID: testChat})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index.Add(textMessage(testChat, 1, "/history on"))
+	index.Add(textMessage(testChat, 2, "   "))
+	index.Add(textMessage(testChat, 3, ""))
+	index.Add(textMessage(42, 4, "not opted in"))
+	index.Add(textMessage(testChat, 5, " kept "))
+
+	if len(index.pending) != 1 {
+		t.Fatalf("pending chats = %d, want 1", len(index.pending))
+	}
+	pending := index.pending[testChat]
+	if len(pending) != 1 || pending[0].MessageID != 5 || pending[0].Text != "kept" || pending[0].Author != "Ana" {
+		t.Fatalf("pending = %+v, want only message 5", pending)
+	}
+}
+
+func TestAddDropsOldestPastMaxPending(t *testing.T) {
+	index := newTestIndex(t, t.TempDir(), &fakeEmbedder{}, 100)
+	index.Enable(models.Chat{ID: testChat})
+
+	for id := 1; id <= maxPending+5; id++ {
+		index.Add(textMessage(testChat, id, "hello"))
+	}
+
+	pending := index.pending[testChat]
+	if len(pending) != maxPending {
+		t.Fatalf("pending = %d, want %d", len(pending), maxPending)
+	}
+	if pending[0].MessageID != 6 {
+		t.Fatalf("oldest pending = %d, want 6", pending[0].MessageID)
+	}
+}
+
+func TestFlushRequeuesFailedBatch(t *testing.T) {
+	dir := t.TempDir()
+	embedder := &fakeEmbedder{err: errors.New("quota")}
+	index := newTestIndex(t, dir, embedder, 100)
+	index.Enable(models.Chat{ID: testChat})
+
+	index.Add(textMessage(testChat, 1, "first"))
+	index.Add(textMessage(testChat, 2, "second"))
+
+	err := index.Flush(context.Background())
+	if err == nil {
+		t.Fatal("Flush succeeded with a failing embedder")
+	}
+	if len(index.pending[testChat]) != 2 {
+		t.Fatalf("pending = %d, want the 2 messages queued again", len(index.pending[testChat]))
+	}
+	if len(index.entries[testChat]) != 0 {
+		t.Fatalf("entries = %d, want none stored", len(index.entries[testChat]))
+	}
+
+	// Newer messages wait behind the failed ones
+	index.Add(textMessage(testChat, 3, "third"))
+	embedder.err = nil
+
+	err = index.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.pending[testChat]) != 0 {
+		t.Fatalf("pending = %d, want none", len(index.pending[testChat]))
+	}
+
+	reopened := newTestIndex(t, dir, embedder, 100)
+	entries := reopened.entries[testChat]
+	if len(entries) != 3 {
+		t.Fatalf("stored entries = %d, want 3", len(entries))
+	}
+	for n, entry := range entries {
+		if entry.MessageID != n+1 {
+			t.Fatalf("entry %d is message %d, want %d", n, entry.MessageID, n+1)
+		}
+		if len(entry.Vector) != len(unknown) {
+			t.Fatalf("entry %d has %d dimensions, want %d", n, len(entry.Vector), len(unknown))
+		}
+	}
+}
+
+func TestSaveCompactsPastMaxMessages(t *testing.T) {
+	dir := t.TempDir()
+	index := newTestIndex(t, dir, &fakeEmbedder{}, 2)
+	index.Enable(models.Chat{ID: testChat})
+
+	for id := 1; id <= 5; id++ {
+		index.Add(textMessage(testChat, id, "hello"))
+		err := index.Flush(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := os.ReadFile(chatPath(filepath.Join(dir, "history"), testChat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(content), "\n"); lines >= 4 {
+		t.Fatalf("history file has %d lines, want it compacted below 4", lines)
+	}
+
+	entries := newTestIndex(t, dir, &fakeEmbedder{}, 2).entries[testChat]
+	if len(entries) != 2 || entries[0].MessageID != 4 || entries[1].MessageID != 5 {
+		t.Fatalf("entries = %+v, want messages 4 and 5", entries)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	embedder := &fakeEmbedder{vectors: map[string][]float32{
+		"Ana: my cat sleeps all day": {1, 0, 0},
+		"Ana: the dog barked again":  {0.6, 0.8, 0},
+		"Ana: new car tyres":         {0, 0.1, 1},
+		"pets":                       {2, 0.5, 0},
+	}}
+	index := newTestIndex(t, t.TempDir(), embedder, 100)
+	index.Enable(models.Chat{ID: testChat, Username: "benegroup"})
+
+	index.Add(textMessage(testChat, 1, "my cat sleeps all day"))
+	index.Add(textMessage(testChat, 2, "the dog barked again"))
+	index.Add(textMessage(testChat, 3, "new car tyres"))
+	err := index.Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Embedded with another model, it can't be compared
+	index.entries[testChat] = append(index.entries[testChat], Entry{MessageID: 4, Text: "pets", Vector: []float32{1, 0}})
+
+	results, err := index.Search(context.Background(), testChat, "pets", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("results = %d, want the limit of 2", len(results))
+	}
+	if results[0].MessageID != 1 || results[1].MessageID != 2 {
+		t.Fatalf("results are messages %d and %d, want 1 and 2", results[0].MessageID, results[1].MessageID)
+	}
+	if results[0].Score < results[1].Score {
+		t.Fatalf("scores %g and %g are not best first", results[0].Score, results[1].Score)
+	}
+	if results[0].Link != "https://t.me/benegroup/1" {
+		t.Fatalf("link = %q", results[0].Link)
+	}
+
+	results, err = index.Search(context.Background(), testChat, "pets", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("results = %d, want 3 without the wrong sized vector", len(results))
+	}
+}
+
+func TestSearchNotEnabled(t *testing.T) {
+	index := newTestIndex(t, t.TempDir(), &fakeEmbedder{}, 100)
+	index.Enable(models.Chat{ID: testChat})
+
+	err := index.Disable(testChat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = index.Search(context.Background(), testChat, "anything", 5)
+	if !errors.Is(err, errNotEnabled) {
+		t.Fatalf("err = %v, want errNotEnabled", err)
+	}
+}
+
+func TestMessageLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		chatID   int64
+		username string
+		want     string
+	}{
+		{"public supergroup", -1001234567890, "benegroup", "https://t.me/benegroup/42"},
+		{"private supergroup", -1001234567890, "", "https://t.me/c/1234567890/42"},
+		{"group", -123456, "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := messageLink(test.chatID, test.username, 42)
+			if got != test.want {
+				t.Fatalf("messageLink = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/victormamede/benebott/internal/storage"
+)
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// Catalog holds the bot strings of every locale, loaded from locales/<locale>.json.
+type Catalog struct {
+	fallback string
+	locales  map[string]map[string]string
+}
+
+// Load reads the embedded locale files. Keys missing from a locale are looked
+// up in fallback.
+func Load(fallback string) (*Catalog, error) {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Catalog{fallback: fallback, locales: map[string]map[string]string{}}
+	for _, entry := range entries {
+		content, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		messages := map[string]string{}
+		err = json.Unmarshal(content, &messages)
+		if err != nil {
+			return nil, fmt.Errorf("locale %s: %w", entry.Name(), err)
+		}
+		c.locales[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+
+	if !c.Has(fallback) {
+		return nil, fmt.Errorf("unknown locale %q", fallback)
+	}
+
+	return c, nil
+}
+
+func (c *Catalog) Has(locale string) bool {
+	_, ok := c.locales[locale]
+	return ok
+}
+
+// Locales returns the available locales, sorted.
+func (c *Catalog) Locales() []string {
+	locales := []string{}
+	for locale := range c.locales {
+		locales = append(locales, locale)
+	}
+	slices.Sort(locales)
+
+	return locales
+}
+
+// T formats the message key of locale with args, like fmt.Sprintf. Unknown
+// keys are returned as is so they are easy to spot.
+func (c *Catalog) T(locale string, key string, args ...any) string {
+	message, ok := c.locales[locale][key]
+	if !ok {
+		message, ok = c.locales[c.fallback][key]
+	}
+	if !ok {
+		return key
+	}
+
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Languages keeps the locale chosen by every chat, persisted in store.
+type Languages struct {
+	catalog *Catalog
+	store   *storage.Store[map[int64]string]
+}
+
+func NewLanguages(catalog *Catalog, store *storage.Store[map[int64]string]) *Languages {
+	return &Languages{catalog: catalog, store: store}
+}
+
+func (l *Languages) Catalog() *Catalog {
+	return l.catalog
+}
+
+// Locale returns the locale of the chat, the catalog fallback by default.
+func (l *Languages) Locale(chatID int64) string {
+	locale := ""
+	l.store.View(func(chats map[int64]string) {
+		locale = chats[chatID]
+	})
+	if !l.catalog.Has(locale) {
+		return l.catalog.fallback
+	}
+
+	return locale
+}
+
+func (l *Languages) Set(chatID int64, locale string) error {
+	if !l.catalog.Has(locale) {
+		return Errorf("language.unknown", locale, strings.Join(l.catalog.Locales(), ", "))
+	}
+
+	return l.store.Update(func(chats *map[int64]string) {
+		(*chats)[chatID] = locale
+	})
+}
+
+// T formats the message key in the locale of the chat.
+func (l *Languages) T(chatID int64, key string, args ...any) string {
+	return l.catalog.T(l.Locale(chatID), key, args...)
+}
+
+// Error translates err in the locale of the chat. Only an *Error is meant for
+// users, false is returned for anything else.
+func (l *Languages) Error(chatID int64, err error) (string, bool) {
+	var localized *Error
+	if !errors.As(err, &localized) {
+		return "", false
+	}
+
+	return l.T(chatID, localized.Key, localized.Args...), true
+}
+
+// Error is an error meant for users, the message key is translated to the
+// chat locale when it is shown.
+type Error struct {
+	Key  string
+	Args []any
+}
+
+func Errorf(key string, args ...any) error {
+	return &Error{Key: key, Args: args}
+}
+
+// english reads the catalog once for Error, outside of any chat.
+var english = sync.OnceValue(func() *Catalog {
+	catalog, _ := Load("en")
+	return catalog
+})
+
+// Error returns the message in English, for logs and the model.
+func (e *Error) Error() string {
+	if catalog := english(); catalog != nil {
+		return catalog.T("en", e.Key, e.Args...)
+	}
+
+	return e.Key
+}
@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/victormamede/benebott/internal/storage"
+)
+
+const maxFacts = 50
+
+// Fact is something remembered about a user, only recalled in the chat where
+// it was learned.
+type Fact struct {
+	ID        int
+	ChatID    int64
+	Text      string
+	CreatedAt time.Time
+}
+
+// Memory keeps the facts about every user keyed by Telegram user ID,
+// persisting them in store.
+type Memory struct {
+	store *storage.Store[map[int64][]Fact]
+}
+
+func New(store *storage.Store[map[int64][]Fact]) *Memory {
+	return &Memory{store: store}
+}
+
+// Remember saves a fact about the user in the chat and returns it.
+func (m *Memory) Remember(chatID int64, userID int64, text string) (Fact, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return Fact{}, errors.New("the fact is empty")
+	}
+
+	var fact Fact
+	var err error
+	saveErr := m.store.Update(func(users *map[int64][]Fact) {
+		facts := (*users)[userID]
+		if len(inChat(facts, chatID)) >= maxFacts {
+			err = fmt.Errorf("at most %d facts are kept per person, forget some first", maxFacts)
+			return
+		}
+
+		id := 1
+		for _, f := range facts {
+			id = max(id, f.ID+1)
+		}
+
+		fact = Fact{ID: id, ChatID: chatID, Text: text, CreatedAt: time.Now()}
+		(*users)[userID] = append(facts, fact)
+	})
+	if saveErr != nil {
+		return Fact{}, saveErr
+	}
+
+	return fact, err
+}
+
+// Recall returns the facts about the user learned in the chat, oldest first.
+func (m *Memory) Recall(chatID int64, userID int64) []Fact {
+	facts := []Fact{}
+	m.store.View(func(users map[int64][]Fact) {
+		facts = inChat(users[userID], chatID)
+	})
+
+	return facts
+}
+
+// RecallChat returns the facts about everyone learned in the chat, keyed by
+// user ID.
+func (m *Memory) RecallChat(chatID int64) map[int64][]Fact {
+	chat := map[int64][]Fact{}
+	m.store.View(func(users map[int64][]Fact) {
+		for userID, facts := range users {
+			if facts := inChat(facts, chatID); len(facts) > 0 {
+				chat[userID] = facts
+			}
+		}
+	})
+
+	return chat
+}
+
+// Forget deletes a fact about the user in the chat, reporting whether it existed.
+func (m *Memory) Forget(chatID int64, userID int64, factID int) (bool, error) {
+	found := false
+	err := m.store.Update(func(users *map[int64][]Fact) {
+		facts := (*users)[userID]
+		(*users)[userID] = slices.DeleteFunc(facts, func(f Fact) bool {
+			matches := f.ChatID == chatID && f.ID == factID
+			found = found || matches
+			return matches
+		})
+		if len((*users)[userID]) == 0 {
+			delete(*users, userID)
+		}
+	})
+
+	return found, err
+}
+
+// ForgetAll deletes every fact about the user in the chat and returns how many.
+func (m *Memory) ForgetAll(chatID int64, userID int64) (int, error) {
+	count := 0
+	err := m.store.Update(func(users *map[int64][]Fact) {
+		before := len((*users)[userID])
+		(*users)[userID] = slices.DeleteFunc((*users)[userID], func(f Fact) bool { return f.ChatID == chatID })
+		count = before - len((*users)[userID])
+		if len((*users)[userID]) == 0 {
+			delete(*users, userID)
+		}
+	})
+
+	return count, err
+}
+
+func inChat(facts []Fact, chatID int64) []Fact {
+	chat := []Fact{}
+	for _, fact := range facts {
+		if fact.ChatID == chatID {
+			chat = append(chat, fact)
+		}
+	}
+
+	return chat
+}
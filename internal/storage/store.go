@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store keeps a value in memory and persists it as a JSON file so that it
+// survives restarts.
+type Store[T any] struct {
+	mu   sync.Mutex
+	path string
+	data T
+}
+
+// Open loads the store saved as name.json in dir, starting from initial when
+// the file doesn't exist yet.
+func Open[T any](dir string, name string, initial T) (*Store[T], error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store[T]{path: filepath.Join(dir, name+".json"), data: initial}
+
+	content, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(content, &s.data)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// View calls fn with the current value. fn must not keep references to it.
+func (s *Store[T]) View(fn func(data T)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fn(s.data)
+}
+
+// Update calls fn to change the value and saves the result.
+func (s *Store[T]) Update(fn func(data *T)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fn(&s.data)
+
+	content, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves a partial file
+	tmp := s.path + ".tmp"
+	err = os.WriteFile(tmp, content, 0o644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path)
+}